@@ -0,0 +1,42 @@
+package deej
+
+import (
+	"syscall"
+	"unsafe"
+
+	ole "github.com/go-ole/go-ole"
+)
+
+// iAudioMeterInformation is the part of the IAudioMeterInformation interface deej needs. go-wca has its IID,
+// but not the interface itself
+type iAudioMeterInformation struct {
+	ole.IUnknown
+}
+
+type iAudioMeterInformationVtbl struct {
+	ole.IUnknownVtbl
+	GetPeakValue            uintptr
+	GetMeteringChannelCount uintptr
+	GetChannelsPeakValues   uintptr
+	QueryHardwareSupport    uintptr
+}
+
+func (v *iAudioMeterInformation) VTable() *iAudioMeterInformationVtbl {
+	return (*iAudioMeterInformationVtbl)(unsafe.Pointer(v.RawVTable))
+}
+
+// GetPeakValue returns the highest sample level (between 0 and 1) of the last metering period
+func (v *iAudioMeterInformation) GetPeakValue(peak *float32) error {
+	hr, _, _ := syscall.Syscall(
+		v.VTable().GetPeakValue,
+		2,
+		uintptr(unsafe.Pointer(v)),
+		uintptr(unsafe.Pointer(peak)),
+		0)
+
+	if hr != 0 {
+		return ole.NewError(hr)
+	}
+
+	return nil
+}
@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cast"
 	"github.com/spf13/viper"
+	"github.com/thoas/go-funk"
 	"go.uber.org/zap"
 
 	"github.com/omriharel/deej/pkg/deej/util"
@@ -16,7 +19,18 @@ import (
 // CanonicalConfig provides application-wide access to configuration fields,
 // as well as loading/file watching logic for deej's configuration file
 type CanonicalConfig struct {
-	SliderMapping *sliderMap
+	// named sets of slider mappings that override the base mapping while a schedule activates them
+	Profiles map[string]*sliderMap
+
+	Schedules []*schedule
+
+	// compiled regex and glob targets, see pattern_targets.go
+	TargetPatterns targetPatterns
+
+	// the stream property keys used by "prop:" targets, which are the only ones worth indexing
+	PropertyTargetKeys map[string]bool
+
+	LogicalChannels map[int]*logicalChannel
 
 	AdditiveIndices []int
 
@@ -31,6 +45,48 @@ type CanonicalConfig struct {
 
 	NoiseReductionLevel string
 
+	FreezeButton int
+
+	StartupSync string
+
+	// sessions matching any of these targets are never added to the session map
+	IgnoreSessions []string
+
+	// how loud a session needs to be to count as playing (for deej.playing and "active:" targets)
+	PlayingPeakThreshold float32
+
+	// how many times per second slider levels are published while anything's subscribed to them
+	MeteringRate int
+
+	Solo struct {
+		Button          int
+		Targets         []string
+		IncludeUnmapped bool
+	}
+
+	PushToTalk struct {
+		Button            int
+		Mode              string
+		ReleaseTail       time.Duration
+		DoublePressWindow time.Duration
+	}
+
+	SystemEvents struct {
+		Lock              systemEventActions
+		Sleep             systemEventActions
+		ReconnectOnResume bool
+	}
+
+	// the slider mapping from the config files, before any profile is applied on top of it,
+	// and the one that's in effect (see SliderMapping). the scheduler swaps profiles from its own goroutine,
+	// so these (along with Profiles) are only accessed with the mapping lock held
+	baseSliderMapping *sliderMap
+	sliderMapping     *sliderMap
+	activeProfile     string
+	mappingLock       sync.Locker
+
+	rememberedVolumes *rememberedVolumes
+
 	logger             *zap.SugaredLogger
 	notifier           Notifier
 	stopWatcherChannel chan bool
@@ -39,6 +95,10 @@ type CanonicalConfig struct {
 
 	userConfig     *viper.Viper
 	internalConfig *viper.Viper
+
+	// the internal config is read on reloads and written when remembered volumes are saved, which happen on
+	// different goroutines - and viper isn't safe for concurrent use
+	internalConfigLock sync.Locker
 }
 
 const (
@@ -54,14 +114,43 @@ const (
 
 	configKeyAdditive            = "additive_indices"
 	configKeySliderMapping       = "slider_mapping"
+	configKeyLogicalChannels     = "logical_channels"
+	configKeyProfiles            = "profiles"
+	configKeySchedules           = "schedules"
 	configKeyInvertSliders       = "invert_sliders"
 	configKeyCOMPort             = "com_port"
 	configKeyBaudRate            = "baud_rate"
 	configKeyNoiseReductionLevel = "noise_reduction"
 	configKeyUseLogVolume        = "use_log_volume"
+	configKeyFreezeButton        = "freeze_button"
+	configKeyStartupSync         = "startup_sync"
+	configKeyIgnoreSessions      = "ignore_sessions"
+	configKeyPlayingThreshold    = "playing_threshold"
+	configKeyMeteringRate        = "metering.rate"
+	configKeySoloButton          = "solo.button"
+	configKeySoloTargets         = "solo.targets"
+	configKeySoloIncludeUnmapped = "solo.include_unmapped"
+
+	configKeyPushToTalkButton            = "push_to_talk.button"
+	configKeyPushToTalkMode              = "push_to_talk.mode"
+	configKeyPushToTalkReleaseTail       = "push_to_talk.release_tail"
+	configKeyPushToTalkDoublePressWindow = "push_to_talk.double_press_window"
+
+	configKeySystemEventsLock              = "system_events.lock"
+	configKeySystemEventsSleep             = "system_events.sleep"
+	configKeySystemEventsReconnectOnResume = "system_events.reconnect_on_resume"
+
+	// these are relative to the lock/sleep keys above
+	systemEventsKeyMute    = "mute"
+	systemEventsKeyLevels  = "levels"
+	systemEventsKeyPause   = "pause"
+	systemEventsKeyRestore = "restore"
 
 	defaultCOMPort  = "COM4"
 	defaultBaudRate = 9600
+
+	defaultPushToTalkReleaseTail       = 250 * time.Millisecond
+	defaultPushToTalkDoublePressWindow = 400 * time.Millisecond
 )
 
 // has to be defined as a non-constant because we're using path.Join
@@ -83,6 +172,9 @@ func NewConfig(logger *zap.SugaredLogger, notifier Notifier) (*CanonicalConfig,
 		notifier:           notifier,
 		reloadConsumers:    []chan bool{},
 		stopWatcherChannel: make(chan bool),
+		rememberedVolumes:  newRememberedVolumes(),
+		mappingLock:        &sync.Mutex{},
+		internalConfigLock: &sync.Mutex{},
 	}
 
 	// distinguish between the user-provided config (config.yaml) and the internal config (logs/preferences.yaml)
@@ -92,10 +184,25 @@ func NewConfig(logger *zap.SugaredLogger, notifier Notifier) (*CanonicalConfig,
 	userConfig.AddConfigPath(userConfigPath)
 
 	userConfig.SetDefault(configKeyAdditive, []int{})
-	userConfig.SetDefault(configKeySliderMapping, map[string][]string{})
+	userConfig.SetDefault(configKeySliderMapping, map[string]interface{}{})
+	userConfig.SetDefault(configKeyLogicalChannels, map[string]interface{}{})
 	userConfig.SetDefault(configKeyInvertSliders, false)
 	userConfig.SetDefault(configKeyCOMPort, defaultCOMPort)
 	userConfig.SetDefault(configKeyBaudRate, defaultBaudRate)
+	userConfig.SetDefault(configKeyFreezeButton, -1)
+	userConfig.SetDefault(configKeyStartupSync, startupSyncApply)
+	userConfig.SetDefault(configKeySoloButton, -1)
+	userConfig.SetDefault(configKeyPlayingThreshold, defaultPlayingPeakThreshold)
+	userConfig.SetDefault(configKeyMeteringRate, defaultMeteringRate)
+	userConfig.SetDefault(configKeyPushToTalkButton, -1)
+	userConfig.SetDefault(configKeyPushToTalkMode, pushToTalkModeTalk)
+	userConfig.SetDefault(configKeyPushToTalkReleaseTail, defaultPushToTalkReleaseTail)
+	userConfig.SetDefault(configKeyPushToTalkDoublePressWindow, defaultPushToTalkDoublePressWindow)
+
+	// by default, a lock's changes are undone on unlock, but a sleep's stay in place after resuming
+	userConfig.SetDefault(configKeySystemEventsLock+"."+systemEventsKeyRestore, true)
+	userConfig.SetDefault(configKeySystemEventsSleep+"."+systemEventsKeyRestore, false)
+	userConfig.SetDefault(configKeySystemEventsReconnectOnResume, true)
 
 	internalConfig := viper.New()
 	internalConfig.SetConfigName(internalConfigName)
@@ -139,9 +246,13 @@ func (cc *CanonicalConfig) Load() error {
 	}
 
 	// load the internal config - this doesn't have to exist, so it can error
+	cc.internalConfigLock.Lock()
 	if err := cc.internalConfig.ReadInConfig(); err != nil {
 		cc.logger.Debugw("Viper failed to read internal config", "error", err, "reminder", "this is fine")
 	}
+	cc.internalConfigLock.Unlock()
+
+	cc.loadRememberedVolumes()
 
 	// canonize the configuration with viper's helpers
 	if err := cc.populateFromVipers(); err != nil {
@@ -151,7 +262,8 @@ func (cc *CanonicalConfig) Load() error {
 
 	cc.logger.Info("Loaded config successfully")
 	cc.logger.Infow("Config values",
-		"sliderMapping", cc.SliderMapping,
+		"sliderMapping", cc.SliderMapping(),
+		"logicalChannels", len(cc.LogicalChannels),
 		"additiveIndices", cc.AdditiveIndices,
 		"connectionInfo", cc.ConnectionInfo,
 		"invertSliders", cc.InvertSliders,
@@ -224,12 +336,47 @@ func (cc *CanonicalConfig) StopWatchingConfigFile() {
 }
 
 func (cc *CanonicalConfig) populateFromVipers() error {
+	cc.internalConfigLock.Lock()
+	internalSliderMapping := cc.internalConfig.GetStringMap(configKeySliderMapping)
+	cc.internalConfigLock.Unlock()
+
 	// merge the slider mappings from the user and internal configs
-	cc.SliderMapping = sliderMapFromConfigs(
-		cc.userConfig.GetStringMapStringSlice(configKeySliderMapping),
-		cc.internalConfig.GetStringMapStringSlice(configKeySliderMapping),
+	baseSliderMapping := sliderMapFromConfigs(
+		cc.userConfig.GetStringMap(configKeySliderMapping),
+		internalSliderMapping,
 	)
 
+	profiles := make(map[string]*sliderMap)
+	for profileName, profileMapping := range cc.userConfig.GetStringMap(configKeyProfiles) {
+		profiles[strings.ToLower(profileName)] = sliderMapFromConfigs(cast.ToStringMap(profileMapping), nil)
+	}
+
+	cc.mappingLock.Lock()
+	cc.baseSliderMapping = baseSliderMapping
+	cc.Profiles = profiles
+
+	// keep whichever profile is active, in case this is a reload
+	cc.applyProfile(cc.activeProfile)
+	cc.mappingLock.Unlock()
+
+	schedules, err := schedulesFromConfig(cc.userConfig.Get(configKeySchedules))
+	if err != nil {
+		cc.logger.Warnw("Invalid schedules specified, ignoring all of them", "key", configKeySchedules, "error", err)
+		cc.notifier.Notify("Invalid schedules!", fmt.Sprintf("Please check the schedules in %s: %s", userConfigFilepath, err))
+
+		schedules = []*schedule{}
+	}
+
+	// the scheduler reads these on its own goroutine
+	cc.mappingLock.Lock()
+	cc.Schedules = schedules
+	cc.mappingLock.Unlock()
+
+	logicalChannels := logicalChannelsFromConfig(cc.logger, cc.userConfig.GetStringMap(configKeyLogicalChannels))
+	dropShadowingLogicalChannels(cc.logger, baseSliderMapping, logicalChannels)
+
+	cc.LogicalChannels = logicalChannels
+
 	cc.AdditiveIndices = cc.userConfig.GetIntSlice(configKeyAdditive)
 
 	cc.logger.Debugw("encoders found", "indices", cc.AdditiveIndices)
@@ -250,12 +397,209 @@ func (cc *CanonicalConfig) populateFromVipers() error {
 	cc.InvertSliders = cc.userConfig.GetBool(configKeyInvertSliders)
 	cc.UseLogVolume = cc.userConfig.GetBool(configKeyUseLogVolume)
 	cc.NoiseReductionLevel = cc.userConfig.GetString(configKeyNoiseReductionLevel)
+	cc.FreezeButton = cc.userConfig.GetInt(configKeyFreezeButton)
+
+	startupSync, valid := parseStartupSyncPolicy(cc.userConfig.GetString(configKeyStartupSync))
+	if !valid {
+		cc.logger.Warnw("Invalid startup sync policy specified, using default value",
+			"key", configKeyStartupSync,
+			"invalidValue", startupSync,
+			"defaultValue", startupSyncApply)
+
+		startupSync = startupSyncApply
+	}
+
+	cc.StartupSync = startupSync
+
+	cc.IgnoreSessions = cc.userConfig.GetStringSlice(configKeyIgnoreSessions)
+
+	cc.PlayingPeakThreshold = float32(cc.userConfig.GetFloat64(configKeyPlayingThreshold))
+	if cc.PlayingPeakThreshold < 0 || cc.PlayingPeakThreshold > 1 {
+		cc.logger.Warnw("Invalid playing threshold specified, using default value",
+			"key", configKeyPlayingThreshold,
+			"invalidValue", cc.PlayingPeakThreshold,
+			"defaultValue", defaultPlayingPeakThreshold)
+
+		cc.PlayingPeakThreshold = defaultPlayingPeakThreshold
+	}
+
+	meteringRate := cc.userConfig.GetInt(configKeyMeteringRate)
+	if meteringRate <= 0 || meteringRate > maxMeteringRate {
+		cc.logger.Warnw("Invalid metering rate specified, using default value",
+			"key", configKeyMeteringRate,
+			"invalidValue", meteringRate,
+			"defaultValue", defaultMeteringRate)
+
+		meteringRate = defaultMeteringRate
+	}
+
+	// the meter reads this on its own goroutine
+	cc.mappingLock.Lock()
+	cc.MeteringRate = meteringRate
+	cc.mappingLock.Unlock()
+
+	cc.Solo.Button = cc.userConfig.GetInt(configKeySoloButton)
+	cc.Solo.Targets = cc.userConfig.GetStringSlice(configKeySoloTargets)
+	cc.Solo.IncludeUnmapped = cc.userConfig.GetBool(configKeySoloIncludeUnmapped)
+
+	cc.PushToTalk.Button = cc.userConfig.GetInt(configKeyPushToTalkButton)
+	cc.PushToTalk.ReleaseTail = cc.userConfig.GetDuration(configKeyPushToTalkReleaseTail)
+	cc.PushToTalk.DoublePressWindow = cc.userConfig.GetDuration(configKeyPushToTalkDoublePressWindow)
+
+	cc.PushToTalk.Mode = strings.ToLower(cc.userConfig.GetString(configKeyPushToTalkMode))
+	if cc.PushToTalk.Mode != pushToTalkModeTalk && cc.PushToTalk.Mode != pushToTalkModeMute {
+		cc.logger.Warnw("Invalid push-to-talk mode specified, using default value",
+			"key", configKeyPushToTalkMode,
+			"invalidValue", cc.PushToTalk.Mode,
+			"defaultValue", pushToTalkModeTalk)
+
+		cc.PushToTalk.Mode = pushToTalkModeTalk
+	}
+
+	cc.SystemEvents.Lock = cc.systemEventActionsFromConfig(configKeySystemEventsLock)
+	cc.SystemEvents.Sleep = cc.systemEventActionsFromConfig(configKeySystemEventsSleep)
+	cc.SystemEvents.ReconnectOnResume = cc.userConfig.GetBool(configKeySystemEventsReconnectOnResume)
+
+	// these have to come last, since they look at targets from all of the above
+	cc.TargetPatterns = cc.compileTargetPatterns()
+	cc.PropertyTargetKeys = propertyTargetKeys(cc.allTargets())
 
 	cc.logger.Debug("Populated config fields from vipers")
 
 	return nil
 }
 
+func (cc *CanonicalConfig) systemEventActionsFromConfig(key string) systemEventActions {
+	return systemEventActions{
+		Mute:    cc.userConfig.GetStringSlice(key + "." + systemEventsKeyMute),
+		Levels:  volumesFromConfigValue(cc.userConfig.Get(key + "." + systemEventsKeyLevels)),
+		Pause:   cc.userConfig.GetBool(key + "." + systemEventsKeyPause),
+		Restore: cc.userConfig.GetBool(key + "." + systemEventsKeyRestore),
+	}
+}
+
+// allTargets returns every target mentioned anywhere in the config, including every profile's
+func (cc *CanonicalConfig) allTargets() []string {
+	targets := []string{}
+
+	collectMappingTargets := func(mapping *sliderMap) {
+		mapping.iterate(func(sliderIdx int, sliderTargets []string) {
+			targets = append(targets, sliderTargets...)
+			targets = append(targets, mapping.options[sliderIdx].excludedTargets()...)
+		})
+	}
+
+	collectMappingTargets(cc.baseSliderMapping)
+	for _, profile := range cc.Profiles {
+		collectMappingTargets(profile)
+	}
+
+	for _, schedule := range cc.Schedules {
+		for target := range schedule.caps {
+			targets = append(targets, target)
+		}
+
+		for target := range schedule.levels {
+			targets = append(targets, target)
+		}
+	}
+
+	targets = append(targets, cc.Solo.Targets...)
+	targets = append(targets, cc.IgnoreSessions...)
+
+	for _, actions := range []systemEventActions{cc.SystemEvents.Lock, cc.SystemEvents.Sleep} {
+		targets = append(targets, actions.Mute...)
+
+		for target := range actions.Levels {
+			targets = append(targets, target)
+		}
+	}
+
+	return funk.UniqString(targets)
+}
+
+// SliderMapping returns the slider mapping that's currently in effect, i.e. the base one with the active
+// profile applied on top of it. it can be swapped at any time, so anything that reads it more than once
+// while handling a single event should hold on to what this returns
+func (cc *CanonicalConfig) SliderMapping() *sliderMap {
+	cc.mappingLock.Lock()
+	defer cc.mappingLock.Unlock()
+
+	return cc.sliderMapping
+}
+
+// currentSchedules returns the schedules from the last time the config was loaded
+func (cc *CanonicalConfig) currentSchedules() []*schedule {
+	cc.mappingLock.Lock()
+	defer cc.mappingLock.Unlock()
+
+	return cc.Schedules
+}
+
+// currentMeteringRate returns the metering rate from the last time the config was loaded
+func (cc *CanonicalConfig) currentMeteringRate() int {
+	cc.mappingLock.Lock()
+	defer cc.mappingLock.Unlock()
+
+	return cc.MeteringRate
+}
+
+// setActiveProfile applies a profile's slider mappings on top of the base ones, or removes them if it's empty
+func (cc *CanonicalConfig) setActiveProfile(profileName string) {
+	cc.mappingLock.Lock()
+	defer cc.mappingLock.Unlock()
+
+	cc.applyProfile(profileName)
+}
+
+// applyProfile assumes the mapping lock is held
+func (cc *CanonicalConfig) applyProfile(profileName string) {
+	if profileName != cc.activeProfile {
+		cc.logger.Infow("Switching profile", "from", cc.activeProfile, "to", profileName)
+	}
+
+	cc.activeProfile = profileName
+
+	profile, ok := cc.Profiles[profileName]
+	if !ok {
+		if profileName != "" {
+			cc.logger.Warnw("Profile not found, using base slider mapping", "profile", profileName)
+		}
+
+		cc.sliderMapping = cc.baseSliderMapping
+		return
+	}
+
+	cc.sliderMapping = cc.baseSliderMapping.withOverrides(profile)
+}
+
+// physicalSliderCount returns the number of sliders the board is expected to send in each frame
+func (cc *CanonicalConfig) physicalSliderCount() int {
+	cc.mappingLock.Lock()
+	baseSliderMapping := cc.baseSliderMapping
+	cc.mappingLock.Unlock()
+
+	return countPhysicalSliders(baseSliderMapping, cc.LogicalChannels)
+}
+
+// countPhysicalSliders counts all mapped sliders that aren't logical channels, along with the sources of
+// logical channels. profiles can't add physical sliders, so only the base mapping counts here
+func countPhysicalSliders(baseSliderMapping *sliderMap, logicalChannels map[int]*logicalChannel) int {
+	physicalSliders := map[int]bool{}
+
+	baseSliderMapping.iterate(func(sliderIdx int, _ []string) {
+		if _, logical := logicalChannels[sliderIdx]; !logical {
+			physicalSliders[sliderIdx] = true
+		}
+	})
+
+	for _, channel := range logicalChannels {
+		physicalSliders[channel.source] = true
+	}
+
+	return len(physicalSliders)
+}
+
 func (cc *CanonicalConfig) onConfigReloaded() {
 	cc.logger.Debug("Notifying consumers about configuration reload")
 
@@ -25,6 +25,7 @@ type Deej struct {
 	config   *CanonicalConfig
 	serial   *SerialIO
 	sessions *sessionMap
+	meter    *sliderMeter
 
 	stopChannel chan bool
 	version     string
@@ -76,6 +77,7 @@ func NewDeej(logger *zap.SugaredLogger, verbose bool) (*Deej, error) {
 	}
 
 	d.sessions = sessions
+	d.meter = newSliderMeter(d, logger)
 
 	logger.Debug("Created deej instance")
 
@@ -105,10 +107,12 @@ func (d *Deej) Initialize() error {
 
 		// run in main thread while waiting on ctrl+C
 		d.setupInterruptHandler()
+		d.setupFreezeToggleHandler()
 		d.run()
 
 	} else {
 		d.setupInterruptHandler()
+		d.setupFreezeToggleHandler()
 		d.initializeTray(d.run)
 	}
 
@@ -135,12 +139,30 @@ func (d *Deej) setupInterruptHandler() {
 	}()
 }
 
+func (d *Deej) setupFreezeToggleHandler() {
+	freezeToggleChannel := util.SetupFreezeToggleHandler()
+
+	go func() {
+		for {
+			signal := <-freezeToggleChannel
+			d.logger.Debugw("Asked to toggle freeze mode", "signal", signal)
+			d.sessions.toggleFreeze()
+		}
+	}()
+}
+
 func (d *Deej) run() {
 	d.logger.Info("Run loop starting")
 
 	// watch the config file for changes
 	go d.config.WatchConfigFileChanges()
 
+	// apply time-of-day volume policies
+	go d.runScheduler()
+
+	// react to the session locking and the system going to sleep
+	go d.watchSystemEvents()
+
 	// connect to the arduino for the first time
 	go func() {
 		if err := d.serial.Start(); err != nil {
@@ -191,7 +213,9 @@ func (d *Deej) stop() error {
 	d.logger.Info("Stopping")
 
 	d.config.StopWatchingConfigFile()
+	d.config.flushRememberedVolumes()
 	d.serial.Stop()
+	d.meter.release()
 
 	// release the session map
 	if err := d.sessions.release(); err != nil {
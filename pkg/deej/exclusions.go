@@ -0,0 +1,79 @@
+package deej
+
+import (
+	"strings"
+
+	"github.com/thoas/go-funk"
+)
+
+// sliderTargetSessions returns a target's sessions, minus any that the slider excludes
+func (m *sessionMap) sliderTargetSessions(options *sliderOptions, target string) []Session {
+	sessions := m.targetSessions(target)
+
+	if len(options.excludedTargets()) == 0 {
+		return sessions
+	}
+
+	result := []Session{}
+
+	for _, session := range sessions {
+		if !m.sessionExcluded(options, session) {
+			result = append(result, session)
+		}
+	}
+
+	return result
+}
+
+// sessionExcluded returns true if the session matches any of the slider's exclusions
+func (m *sessionMap) sessionExcluded(options *sliderOptions, session Session) bool {
+	for _, exclusion := range options.excludedTargets() {
+		if m.sessionMatchesTarget(session, exclusion) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sessionIgnored returns true if the session matches any of the globally ignored targets.
+// special targets (deej.current, deej.unmapped) make no sense there, and are skipped
+func (m *sessionMap) sessionIgnored(session Session) bool {
+	for _, target := range m.deej.config.IgnoreSessions {
+		if m.targetHasSpecialTransform(strings.ToLower(target)) {
+			continue
+		}
+
+		if m.sessionMatchesTarget(session, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// processNameIgnored is sessionIgnored for a bare process name, for when there's no session to look at yet
+func (m *sessionMap) processNameIgnored(name string) bool {
+	for _, target := range m.deej.config.IgnoreSessions {
+		if pattern, ok := m.deej.config.targetPattern(target); ok {
+			if pattern != nil && pattern.MatchString(name) {
+				return true
+			}
+
+			continue
+		}
+
+		if strings.ToLower(target) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withoutIgnoredProcessNames filters out process names that are globally ignored
+func (m *sessionMap) withoutIgnoredProcessNames(names []string) []string {
+	return funk.FilterString(names, func(name string) bool {
+		return !m.processNameIgnored(name)
+	})
+}
@@ -0,0 +1,169 @@
+package deej
+
+import (
+	"sync"
+	"time"
+
+	"github.com/omriharel/deej/pkg/deej/util"
+)
+
+// freezeState lets the user temporarily detach the hardware from their audio sessions (e.g. while cleaning
+// the faders). serial input keeps being read, but slider events are discarded until the freeze ends.
+// afterwards, each slider that moved during the freeze is only re-engaged once it's moved again
+type freezeState struct {
+	frozen bool
+
+	// the last value seen for each slider while frozen
+	frozenValues map[int]float32
+
+	// sliders that moved during the freeze and haven't been moved since it ended, with their value at that time
+	awaitingMovement map[int]float32
+
+	// used to tell a double-press of the freeze button from a single one
+	lastButtonPress   time.Time
+	pendingMuteToggle *time.Timer
+
+	consumers []chan bool
+
+	lock sync.Locker
+}
+
+const (
+	// two presses of the freeze button within this window toggle the freeze. a single press
+	// still toggles mute as usual, but only once this window has passed
+	freezeGestureWindow = 400 * time.Millisecond
+)
+
+func newFreezeState() *freezeState {
+	return &freezeState{
+		frozenValues:     make(map[int]float32),
+		awaitingMovement: make(map[int]float32),
+		consumers:        []chan bool{},
+		lock:             &sync.Mutex{},
+	}
+}
+
+// SubscribeToFreezeChanges returns an unbuffered channel that receives the new freeze state whenever it changes
+func (m *sessionMap) SubscribeToFreezeChanges() chan bool {
+	m.freeze.lock.Lock()
+	defer m.freeze.lock.Unlock()
+
+	c := make(chan bool)
+	m.freeze.consumers = append(m.freeze.consumers, c)
+
+	return c
+}
+
+func (m *sessionMap) frozen() bool {
+	m.freeze.lock.Lock()
+	defer m.freeze.lock.Unlock()
+
+	return m.freeze.frozen
+}
+
+func (m *sessionMap) toggleFreeze() {
+	m.setFrozen(!m.frozen())
+}
+
+func (m *sessionMap) setFrozen(frozen bool) {
+	m.freeze.lock.Lock()
+
+	if m.freeze.frozen == frozen {
+		m.freeze.lock.Unlock()
+		return
+	}
+
+	m.freeze.frozen = frozen
+
+	if frozen {
+		m.freeze.frozenValues = make(map[int]float32)
+	} else {
+		m.freeze.awaitingMovement = m.freeze.frozenValues
+		m.freeze.frozenValues = make(map[int]float32)
+	}
+
+	consumers := m.freeze.consumers
+	m.freeze.lock.Unlock()
+
+	if frozen {
+		m.logger.Info("Sliders frozen, ignoring hardware input until unfrozen")
+	} else {
+		m.logger.Info("Sliders unfrozen, each slider will re-engage once it's moved")
+	}
+
+	for _, consumer := range consumers {
+		consumer <- frozen
+	}
+}
+
+// filterFrozenEvent returns true if the given slider event's value should be discarded because of a freeze,
+// be it an active one or one that ended before this slider was moved again. in the latter case the slider's
+// mute button isn't part of the freeze anymore, so the second value is true if its mute toggle should still apply
+func (m *sessionMap) filterFrozenEvent(event SliderEvent) (bool, bool) {
+	m.freeze.lock.Lock()
+	defer m.freeze.lock.Unlock()
+
+	if m.freeze.frozen {
+		m.freeze.frozenValues[event.SliderID] = event.PercentValue
+		return true, false
+	}
+
+	frozenValue, awaiting := m.freeze.awaitingMovement[event.SliderID]
+	if !awaiting {
+		return false, false
+	}
+
+	// noise shouldn't count as the slider being moved
+	if !util.SignificantlyDifferent(frozenValue, event.PercentValue, m.deej.config.NoiseReductionLevel) {
+		return true, event.ToggleMute
+	}
+
+	m.logger.Debugw("Slider moved after freeze, re-engaging", "sliderIdx", event.SliderID)
+	delete(m.freeze.awaitingMovement, event.SliderID)
+
+	return false, false
+}
+
+// handleFreezeButton intercepts presses of the configured freeze button. it returns the event
+// that should be handled in its place, with the mute toggle removed when it's held back
+func (m *sessionMap) handleFreezeButton(event SliderEvent) SliderEvent {
+	if !event.ToggleMute || event.SliderID != m.deej.config.FreezeButton {
+		return event
+	}
+
+	m.freeze.lock.Lock()
+	defer m.freeze.lock.Unlock()
+
+	now := time.Now()
+
+	// second press within the window: this is the freeze gesture, so the first press shouldn't mute anything
+	if m.freeze.pendingMuteToggle != nil && m.freeze.lastButtonPress.Add(freezeGestureWindow).After(now) {
+		m.freeze.pendingMuteToggle.Stop()
+		m.freeze.pendingMuteToggle = nil
+
+		go m.toggleFreeze()
+
+		event.ToggleMute = false
+		return event
+	}
+
+	m.freeze.lastButtonPress = now
+
+	// first press: hold back its mute toggle until we know it's not a double-press
+	muteEvent := SliderEvent{
+		SliderID:     event.SliderID,
+		PercentValue: event.PercentValue,
+		ToggleMute:   true,
+	}
+
+	m.freeze.pendingMuteToggle = time.AfterFunc(freezeGestureWindow, func() {
+		m.freeze.lock.Lock()
+		m.freeze.pendingMuteToggle = nil
+		m.freeze.lock.Unlock()
+
+		m.delayedSliderEvents <- muteEvent
+	})
+
+	event.ToggleMute = false
+	return event
+}
@@ -0,0 +1,193 @@
+package deej
+
+import (
+	"fmt"
+
+	"github.com/jfreymuth/pulse"
+	"github.com/jfreymuth/pulse/proto"
+	"go.uber.org/zap"
+)
+
+// paLevelMeter measures sessions with peak-detect record streams, on a connection of its own so that
+// recorded data doesn't have to go through the session finder's event handling.
+// in peak-detect mode the server sends one value per period (the highest sample in it) instead of actual audio,
+// which is what keeps this cheap - but it also means rms is calculated over those peaks, and is only an approximation
+type paLevelMeter struct {
+	logger *zap.SugaredLogger
+	client *pulse.Client
+
+	// how many peak values each stream receives per second
+	sampleRate int
+
+	streams map[Session]*paMeterStream
+}
+
+type paMeterStream struct {
+	record *pulse.RecordStream
+
+	// the source being recorded, to notice when an app's stream moves to another sink
+	sourceIndex uint32
+
+	accumulator *levelAccumulator
+}
+
+const (
+	// each metering period gets a few peak values, so that short spikes between reads aren't missed
+	paMeterSamplesPerPeriod = 4
+
+	paMeterStreamName = "deej level meter"
+)
+
+func newLevelMeter(logger *zap.SugaredLogger, rate int) (levelMeter, error) {
+	client, err := pulse.NewClient(pulse.ClientApplicationName("deej"))
+	if err != nil {
+		logger.Warnw("Failed to establish PulseAudio connection for metering", "error", err)
+		return nil, fmt.Errorf("establish PulseAudio connection: %w", err)
+	}
+
+	return &paLevelMeter{
+		logger:     logger,
+		client:     client,
+		sampleRate: rate * paMeterSamplesPerPeriod,
+		streams:    make(map[Session]*paMeterStream),
+	}, nil
+}
+
+func (m *paLevelMeter) watch(sessions []Session) {
+	watched := make(map[Session]bool)
+
+	for _, session := range sessions {
+		sourceIndex, sinkInputIndex, ok := m.meterSource(session)
+		if !ok {
+			continue
+		}
+
+		watched[session] = true
+
+		if stream, ok := m.streams[session]; ok {
+			if stream.sourceIndex == sourceIndex {
+				continue
+			}
+
+			// the app moved to another sink, so its old stream is recording the wrong thing
+			stream.record.Close()
+			delete(m.streams, session)
+		}
+
+		stream, err := m.newStream(sourceIndex, sinkInputIndex)
+		if err != nil {
+			m.logger.Debugw("Failed to create meter stream", "session", session, "error", err)
+			continue
+		}
+
+		m.streams[session] = stream
+	}
+
+	for session, stream := range m.streams {
+		if !watched[session] {
+			stream.record.Close()
+			delete(m.streams, session)
+		}
+	}
+}
+
+// meterSource returns the source to record for the given session, and the sink input to record directly
+// (proto.Undefined for master and device sessions, which record their entire sink or source)
+func (m *paLevelMeter) meterSource(session Session) (uint32, uint32, bool) {
+	switch session := session.(type) {
+	case *paSession:
+		inputRequest := proto.GetSinkInputInfo{SinkInputIndex: session.sinkInputIndex}
+		inputReply := proto.GetSinkInputInfoReply{}
+
+		if err := m.client.RawRequest(&inputRequest, &inputReply); err != nil {
+			return 0, 0, false
+		}
+
+		monitorIndex, ok := m.monitorSource(inputReply.SinkIndex)
+		if !ok {
+			return 0, 0, false
+		}
+
+		return monitorIndex, session.sinkInputIndex, true
+
+	case *masterSession:
+		if !session.isOutput {
+			return session.streamIndex, proto.Undefined, true
+		}
+
+		monitorIndex, ok := m.monitorSource(session.streamIndex)
+		if !ok {
+			return 0, 0, false
+		}
+
+		return monitorIndex, proto.Undefined, true
+	}
+
+	return 0, 0, false
+}
+
+func (m *paLevelMeter) monitorSource(sinkIndex uint32) (uint32, bool) {
+	request := proto.GetSinkInfo{SinkIndex: sinkIndex}
+	reply := proto.GetSinkInfoReply{}
+
+	if err := m.client.RawRequest(&request, &reply); err != nil {
+		return 0, false
+	}
+
+	return reply.MonitorSourceIndex, true
+}
+
+func (m *paLevelMeter) newStream(sourceIndex uint32, sinkInputIndex uint32) (*paMeterStream, error) {
+	stream := &paMeterStream{
+		sourceIndex: sourceIndex,
+		accumulator: newLevelAccumulator(),
+	}
+
+	writer := pulse.Float32Writer(func(samples []float32) (int, error) {
+		stream.accumulator.add(samples)
+		return len(samples), nil
+	})
+
+	record, err := m.client.NewRecord(writer,
+		pulse.RecordSampleRate(m.sampleRate),
+		pulse.RecordBufferFragmentSize(4),
+		pulse.RecordMediaName(paMeterStreamName),
+		pulse.RecordRawOption(func(request *proto.CreateRecordStream) {
+			request.SourceIndex = sourceIndex
+			request.DirectOnInputIndex = sinkInputIndex
+			request.PeakDetect = true
+
+			// metering shouldn't keep an otherwise idle device awake
+			request.DontInhibitAutoSuspend = true
+		}))
+
+	if err != nil {
+		return nil, fmt.Errorf("create record stream: %w", err)
+	}
+
+	record.Start()
+	stream.record = record
+
+	return stream, nil
+}
+
+func (m *paLevelMeter) read() map[Session]levelReading {
+	readings := make(map[Session]levelReading)
+
+	for session, stream := range m.streams {
+		readings[session] = stream.accumulator.take()
+	}
+
+	return readings
+}
+
+func (m *paLevelMeter) release() error {
+	for session, stream := range m.streams {
+		stream.record.Close()
+		delete(m.streams, session)
+	}
+
+	m.client.Close()
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package deej
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+
+	ole "github.com/go-ole/go-ole"
+	"go.uber.org/zap"
+)
+
+// wcaLevelMeter polls each session's IAudioMeterInformation. windows only reports a peak per metering period,
+// so the peak stands in for rms as well. master and device sessions don't have a session meter, and read as silent.
+// refreshing the session map releases every session, so the meter holds its own reference to each session's
+// meter instead of going through the session.
+// COM calls need an initialized thread, so the meter keeps the goroutine that created it on its thread until it's
+// released - which means it must only be used from that goroutine
+type wcaLevelMeter struct {
+	logger *zap.SugaredLogger
+	meters map[Session]*iAudioMeterInformation
+}
+
+func newLevelMeter(logger *zap.SugaredLogger, rate int) (levelMeter, error) {
+	runtime.LockOSThread()
+
+	if err := ole.CoInitializeEx(0, ole.COINIT_MULTITHREADED); err != nil {
+
+		// E_FALSE means this thread was already initialized, which is just as good
+		const eFalse = 1
+		oleError := &ole.OleError{}
+
+		if !errors.As(err, &oleError) || oleError.Code() != eFalse {
+			logger.Warnw("Failed to call CoInitializeEx for metering", "error", err)
+			runtime.UnlockOSThread()
+
+			return nil, fmt.Errorf("call CoInitializeEx: %w", err)
+		}
+	}
+
+	return &wcaLevelMeter{
+		logger: logger,
+		meters: make(map[Session]*iAudioMeterInformation),
+	}, nil
+}
+
+func (m *wcaLevelMeter) watch(sessions []Session) {
+	watched := make(map[Session]bool)
+
+	for _, session := range sessions {
+		watched[session] = true
+
+		if _, ok := m.meters[session]; ok {
+			continue
+		}
+
+		metered, ok := session.(*wcaSession)
+		if !ok {
+			continue
+		}
+
+		if meter, ok := metered.acquireMeter(); ok {
+			m.meters[session] = meter
+		}
+	}
+
+	for session, meter := range m.meters {
+		if !watched[session] {
+			meter.Release()
+			delete(m.meters, session)
+		}
+	}
+}
+
+func (m *wcaLevelMeter) read() map[Session]levelReading {
+	readings := make(map[Session]levelReading)
+
+	for session, meter := range m.meters {
+		var peak float32
+
+		if err := meter.GetPeakValue(&peak); err != nil {
+			m.logger.Debugw("Failed to get session peak", "session", session.Key(), "error", err)
+			continue
+		}
+
+		readings[session] = levelReading{peak: peak, rms: peak}
+	}
+
+	return readings
+}
+
+func (m *wcaLevelMeter) release() error {
+	for session, meter := range m.meters {
+		meter.Release()
+		delete(m.meters, session)
+	}
+
+	ole.CoUninitialize()
+	runtime.UnlockOSThread()
+
+	return nil
+}
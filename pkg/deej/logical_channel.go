@@ -0,0 +1,127 @@
+package deej
+
+import (
+	"strconv"
+
+	"github.com/spf13/cast"
+	"go.uber.org/zap"
+)
+
+// logicalChannel derives a virtual slider from a physical one, by taking a range of the
+// physical slider's values and stretching (or shrinking) it onto a range of output values
+type logicalChannel struct {
+	source int
+
+	inputMin  float32
+	inputMax  float32
+	outputMin float32
+	outputMax float32
+}
+
+const (
+	logicalChannelKeySource = "source"
+	logicalChannelKeyInput  = "input"
+	logicalChannelKeyOutput = "output"
+)
+
+func logicalChannelsFromConfig(logger *zap.SugaredLogger, config map[string]interface{}) map[int]*logicalChannel {
+	channels := make(map[int]*logicalChannel)
+
+	for channelIdxString, value := range config {
+		key := configKeyLogicalChannels + "." + channelIdxString
+
+		channelIdx, err := strconv.Atoi(channelIdxString)
+		if err != nil {
+			logger.Warnw("Invalid logical channel index specified, ignoring channel", "key", key, "invalidValue", channelIdxString)
+			continue
+		}
+
+		channelMap := cast.ToStringMap(value)
+
+		// cast reads a missing source as slider 0, so look for it first
+		sourceValue, ok := channelMap[logicalChannelKeySource]
+		sourceIdx, err := cast.ToIntE(sourceValue)
+		if !ok || err != nil {
+			logger.Warnw("Invalid or missing logical channel source specified, ignoring channel",
+				"key", key+"."+logicalChannelKeySource,
+				"invalidValue", channelMap[logicalChannelKeySource])
+
+			continue
+		}
+
+		channel := &logicalChannel{source: sourceIdx}
+		channel.inputMin, channel.inputMax = rangeFromConfigValue(channelMap[logicalChannelKeyInput])
+		channel.outputMin, channel.outputMax = rangeFromConfigValue(channelMap[logicalChannelKeyOutput])
+
+		// an empty input range can't be stretched onto anything
+		if channel.inputMin == channel.inputMax {
+			logger.Warnw("Empty logical channel input range specified, ignoring channel",
+				"key", key+"."+logicalChannelKeyInput,
+				"invalidValue", channelMap[logicalChannelKeyInput])
+
+			continue
+		}
+
+		channels[channelIdx] = channel
+	}
+
+	return channels
+}
+
+// dropShadowingLogicalChannels removes logical channels whose index belongs to a physical slider. the board's
+// sliders are numbered by their position in each frame, so such a channel would silently take over a real slider
+func dropShadowingLogicalChannels(
+	logger *zap.SugaredLogger,
+	baseSliderMapping *sliderMap,
+	channels map[int]*logicalChannel,
+) {
+	physicalSliders := countPhysicalSliders(baseSliderMapping, channels)
+
+	for channelIdx := range channels {
+		if channelIdx < physicalSliders {
+			logger.Warnw("Logical channel index is taken by a physical slider, ignoring channel",
+				"key", configKeyLogicalChannels+"."+strconv.Itoa(channelIdx),
+				"invalidValue", channelIdx,
+				"physicalSliders", physicalSliders)
+
+			delete(channels, channelIdx)
+		}
+	}
+}
+
+// rangeFromConfigValue reads a [min, max] pair, defaulting to the full 0.0 - 1.0 range
+func rangeFromConfigValue(value interface{}) (float32, float32) {
+	bounds := cast.ToSlice(value)
+	if len(bounds) != 2 {
+		return 0, 1
+	}
+
+	return cast.ToFloat32(bounds[0]), cast.ToFloat32(bounds[1])
+}
+
+// transform maps the source slider's value onto this channel's output range. values
+// outside the input range are clamped to it, so that e.g. the lower half of a split slider
+// stays at its maximum while the upper half is being moved.
+// additive values (encoder steps) aren't positions, so they're only scaled
+func (c *logicalChannel) transform(value float32, additive bool) float32 {
+	if additive {
+		return value * c.scale()
+	}
+
+	low, high := c.inputMin, c.inputMax
+	if low > high {
+		low, high = high, low
+	}
+
+	if value < low {
+		value = low
+	} else if value > high {
+		value = high
+	}
+
+	return c.outputMin + (value-c.inputMin)*c.scale()
+}
+
+func (c *logicalChannel) scale() float32 {
+	return (c.outputMax - c.outputMin) / (c.inputMax - c.inputMin)
+}
@@ -0,0 +1,75 @@
+package deej
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogicalChannelsFromConfig(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	logger := zap.New(core).Sugar()
+
+	channels := logicalChannelsFromConfig(logger, map[string]interface{}{
+		"3":     map[string]interface{}{"source": 0, "input": []interface{}{0, 0.5}},
+		"four":  map[string]interface{}{"source": 0},
+		"5":     map[string]interface{}{"input": []interface{}{0, 0.5}},
+		"6":     map[string]interface{}{"source": 0, "input": []interface{}{0.5, 0.5}},
+		"7":     map[string]interface{}{"source": 1, "output": []interface{}{1, 0}},
+		"eight": "not a channel",
+	})
+
+	if len(channels) != 2 || channels[3] == nil || channels[7] == nil {
+		t.Fatalf("expected only channels 3 and 7, got %v", channels)
+	}
+
+	// every ignored channel should say why
+	if logs.Len() != 4 {
+		t.Fatalf("expected a warning per ignored channel, got %v", logs.All())
+	}
+
+	for _, entry := range logs.All() {
+		fields := entry.ContextMap()
+		if _, ok := fields["key"]; !ok {
+			t.Fatalf("expected the warning to name the key: %v", entry)
+		}
+
+		if _, ok := fields["invalidValue"]; !ok {
+			t.Fatalf("expected the warning to include the invalid value: %v", entry)
+		}
+	}
+}
+
+func TestDropShadowingLogicalChannels(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	logger := zap.New(core).Sugar()
+
+	// sliders 0 and 2 are physical, so the board sends two of them and channel 1 would take over the second
+	mapping := sliderMapFromConfigs(map[string]interface{}{
+		"0": "master",
+		"1": "spotify",
+		"2": "discord",
+		"3": "chrome",
+	}, nil)
+
+	channels := map[int]*logicalChannel{
+		1: {source: 0, inputMin: 0, inputMax: 0.5, outputMax: 1},
+		3: {source: 0, inputMin: 0.5, inputMax: 1, outputMax: 1},
+	}
+
+	dropShadowingLogicalChannels(logger, mapping, channels)
+
+	if _, ok := channels[1]; ok {
+		t.Fatalf("expected channel 1 to be dropped, got %v", channels)
+	}
+
+	if len(channels) != 1 || channels[3] == nil {
+		t.Fatalf("expected channel 3 to stay, got %v", channels)
+	}
+
+	if logs.Len() != 1 {
+		t.Fatalf("expected a warning for the dropped channel, got %v", logs.All())
+	}
+}
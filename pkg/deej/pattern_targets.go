@@ -0,0 +1,255 @@
+package deej
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/thoas/go-funk"
+)
+
+// targetPatterns holds every regex and glob target from the config, compiled once when it's loaded.
+// they're keyed by the target as written, since lowercasing a regex can change what it matches
+type targetPatterns map[string]*regexp.Regexp
+
+const (
+	// targets starting with this are regular expressions, e.g. "re:^unrealeditor.*"
+	targetRegexPrefix = "re:"
+
+	// targets containing any of these are globs, e.g. "*.exe"
+	targetGlobCharacters = "*?["
+)
+
+// isPatternTarget returns true if the given target is a regex or glob rather than a plain name
+func isPatternTarget(target string) bool {
+	if strings.HasPrefix(strings.ToLower(target), targetRegexPrefix) {
+		return true
+	}
+
+	// special, property and "active:" targets have their own syntax, so they're never globs themselves
+	if strings.HasPrefix(target, specialTargetTransformPrefix) ||
+		strings.HasPrefix(strings.ToLower(target), propertyTargetPrefix) ||
+		strings.HasPrefix(strings.ToLower(target), activeTargetPrefix) {
+		return false
+	}
+
+	return strings.ContainsAny(target, targetGlobCharacters)
+}
+
+// compilePatternTarget turns a regex or glob target into a case-insensitive regular expression
+func compilePatternTarget(target string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(strings.ToLower(target), targetRegexPrefix) {
+		expression := target[len(targetRegexPrefix):]
+
+		pattern, err := regexp.Compile("(?i)" + expression)
+		if err != nil {
+			return nil, fmt.Errorf("compile regex: %w", err)
+		}
+
+		return pattern, nil
+	}
+
+	// let path.Match tell us about malformed globs (like an unclosed bracket) before converting them
+	if _, err := path.Match(target, ""); err != nil {
+		return nil, fmt.Errorf("parse glob: %w", err)
+	}
+
+	pattern, err := regexp.Compile("(?i)" + globToRegexp(target))
+	if err != nil {
+		return nil, fmt.Errorf("compile glob: %w", err)
+	}
+
+	return pattern, nil
+}
+
+// globToRegexp converts a glob (in path.Match syntax) to an anchored regular expression.
+// unlike path.Match, wildcards here also match slashes - session names aren't paths
+func globToRegexp(glob string) string {
+	var builder strings.Builder
+	builder.WriteString("^")
+
+	inClass := false
+
+	for idx := 0; idx < len(glob); idx++ {
+		char := glob[idx]
+
+		switch {
+		case char == '\\' && idx+1 < len(glob):
+			idx++
+			builder.WriteString(regexp.QuoteMeta(string(glob[idx])))
+
+		case inClass:
+			if char == ']' {
+				inClass = false
+			}
+
+			builder.WriteByte(char)
+
+		case char == '[':
+			inClass = true
+			builder.WriteByte(char)
+
+			// path.Match negates classes with ^, which regexp already understands - but also allow the more common !
+			if idx+1 < len(glob) && glob[idx+1] == '!' {
+				idx++
+				builder.WriteByte('^')
+			}
+
+		case char == '*':
+			builder.WriteString(".*")
+
+		case char == '?':
+			builder.WriteString(".")
+
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+
+	builder.WriteString("$")
+
+	return builder.String()
+}
+
+// compileTargetPatterns compiles every regex and glob target mentioned anywhere in the config,
+// and lets the user know about any that are invalid (those end up matching nothing)
+func (cc *CanonicalConfig) compileTargetPatterns() targetPatterns {
+	targets := cc.allTargets()
+
+	patterns := targetPatterns{}
+	invalidTargets := []string{}
+
+	for _, target := range targets {
+		if innerTarget, ok := parseActiveTarget(target); ok {
+			target = innerTarget
+		}
+
+		if !isPatternTarget(target) {
+			continue
+		}
+
+		pattern, err := compilePatternTarget(target)
+		if err != nil {
+			cc.logger.Warnw("Invalid target pattern, it won't match anything", "target", target, "error", err)
+			invalidTargets = append(invalidTargets, target)
+
+			continue
+		}
+
+		patterns[target] = pattern
+	}
+
+	cc.warnAboutKeyedRegexTargets()
+
+	if len(invalidTargets) > 0 {
+		cc.notifier.Notify("Invalid target pattern!",
+			fmt.Sprintf("These targets in %s won't match anything: %s", userConfigFilepath, strings.Join(invalidTargets, ", ")))
+	}
+
+	cc.logger.Debugw("Compiled target patterns", "amount", len(patterns))
+
+	return patterns
+}
+
+// warnAboutKeyedRegexTargets lets the user know about regex targets given as map keys (schedule caps and levels,
+// system event levels and modifiers in map form). viper lowercases those before we ever see them, which silently
+// turns escapes like \D, \S or \W into their opposites
+func (cc *CanonicalConfig) warnAboutKeyedRegexTargets() {
+	keyedTargets := []string{}
+
+	collectMappingTargets := func(mapping *sliderMap) {
+		mapping.iterate(func(sliderIdx int, _ []string) {
+			if options := mapping.options[sliderIdx]; options != nil {
+				keyedTargets = append(keyedTargets, options.keyedTargets...)
+			}
+		})
+	}
+
+	collectMappingTargets(cc.baseSliderMapping)
+	for _, profile := range cc.Profiles {
+		collectMappingTargets(profile)
+	}
+
+	for _, schedule := range cc.Schedules {
+		for target := range schedule.caps {
+			keyedTargets = append(keyedTargets, target)
+		}
+
+		for target := range schedule.levels {
+			keyedTargets = append(keyedTargets, target)
+		}
+	}
+
+	for _, actions := range []systemEventActions{cc.SystemEvents.Lock, cc.SystemEvents.Sleep} {
+		for target := range actions.Levels {
+			keyedTargets = append(keyedTargets, target)
+		}
+	}
+
+	for _, target := range funk.UniqString(keyedTargets) {
+		if innerTarget, ok := parseActiveTarget(target); ok {
+			target = innerTarget
+		}
+
+		if strings.HasPrefix(strings.ToLower(target), targetRegexPrefix) {
+			cc.logger.Warnw("Regex target used as a map key, it was lowercased when the config was read",
+				"target", target,
+				"hint", "uppercase escapes like \\D or \\S won't work here, use a glob or a lowercase-safe regex instead")
+		}
+	}
+}
+
+// targetPattern returns the compiled pattern for a regex or glob target, or false if the target is a plain name.
+// invalid patterns return a nil pattern, since they were already reported when the config was loaded
+func (cc *CanonicalConfig) targetPattern(target string) (*regexp.Regexp, bool) {
+	if !isPatternTarget(target) {
+		return nil, false
+	}
+
+	if pattern, ok := cc.TargetPatterns[target]; ok {
+		return pattern, true
+	}
+
+	// not every target passes through the config (e.g. ones added at runtime), so compile those on the spot
+	pattern, _ := compilePatternTarget(target)
+
+	return pattern, true
+}
+
+// sessionMatchesPattern returns true if the session's key (or any of its other names) matches the pattern.
+// master, system and mic are left out on purpose, so that something like "*" doesn't unexpectedly grab them
+func (m *sessionMap) sessionMatchesPattern(session Session, pattern *regexp.Regexp) bool {
+	if pattern == nil {
+		return false
+	}
+
+	if funk.ContainsString([]string{masterSessionName, systemSessionName, inputSessionName}, session.Key()) {
+		return false
+	}
+
+	if pattern.MatchString(session.Key()) {
+		return true
+	}
+
+	for _, name := range sessionNames(session) {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchingPatternKeys returns the keys of all current sessions that match the pattern
+func (m *sessionMap) matchingPatternKeys(pattern *regexp.Regexp) []string {
+	keys := []string{}
+
+	for _, session := range m.allSessions() {
+		if m.sessionMatchesPattern(session, pattern) {
+			keys = append(keys, session.Key())
+		}
+	}
+
+	return funk.UniqString(keys)
+}
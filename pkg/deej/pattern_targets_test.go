@@ -0,0 +1,79 @@
+package deej
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestCompilePatternTarget(t *testing.T) {
+	tests := []struct {
+		target  string
+		matches []string
+		misses  []string
+		wantErr bool
+	}{
+		{target: "*.exe", matches: []string{"game.exe", "GAME.EXE", "dir/game.exe"}, misses: []string{"game.exe.bak"}},
+		{target: "game-v?.exe", matches: []string{"game-v2.exe"}, misses: []string{"game-v10.exe"}},
+		{target: "[!a]pp", matches: []string{"bpp"}, misses: []string{"app"}},
+		{target: "re:^unreal", matches: []string{"unrealeditor", "UnrealEditor"}, misses: []string{"notunreal"}},
+		{target: `re:^\D+$`, matches: []string{"chrome"}, misses: []string{"game2"}},
+		{target: "re:(", wantErr: true},
+		{target: "[a", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			pattern, err := compilePatternTarget(test.target)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", pattern)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, name := range test.matches {
+				if !pattern.MatchString(name) {
+					t.Errorf("expected %q to match %q", test.target, name)
+				}
+			}
+
+			for _, name := range test.misses {
+				if pattern.MatchString(name) {
+					t.Errorf("expected %q not to match %q", test.target, name)
+				}
+			}
+		})
+	}
+}
+
+func TestTargetPatternKeepsCase(t *testing.T) {
+	cc := &CanonicalConfig{logger: zap.NewNop().Sugar()}
+
+	cc.TargetPatterns = targetPatterns{}
+	for _, target := range []string{`re:^\D+$`, `re:^\d+$`} {
+		pattern, err := compilePatternTarget(target)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		cc.TargetPatterns[target] = pattern
+	}
+
+	// these only differ in case, and mean opposite things
+	nonDigits, _ := cc.targetPattern(`re:^\D+$`)
+	digits, _ := cc.targetPattern(`re:^\d+$`)
+
+	if !nonDigits.MatchString("chrome") || nonDigits.MatchString("123") {
+		t.Fatalf("expected the uppercase escape to keep its meaning")
+	}
+
+	if !digits.MatchString("123") || digits.MatchString("chrome") {
+		t.Fatalf("expected the lowercase escape to keep its meaning")
+	}
+}
@@ -0,0 +1,64 @@
+package deej
+
+import (
+	"strings"
+
+	"github.com/thoas/go-funk"
+)
+
+const (
+	// qualifies a target such that it only applies to its sessions that are currently audible, e.g. "active:chrome.exe"
+	activeTargetPrefix = "active:"
+
+	defaultPlayingPeakThreshold = 0.01
+)
+
+// parseActiveTarget returns the inner target of an "active:" target, or false if the target isn't one
+func parseActiveTarget(target string) (string, bool) {
+	if !strings.HasPrefix(strings.ToLower(target), activeTargetPrefix) {
+		return "", false
+	}
+
+	return strings.TrimSpace(target[len(activeTargetPrefix):]), true
+}
+
+// sessionPlaying returns true if the session is producing audio: it's uncorked (where that's known, i.e. on linux)
+// or its peak level is above the configured threshold (where that's known, i.e. on windows).
+// sessions that report neither (like master or mic) are never considered to be playing
+func (m *sessionMap) sessionPlaying(session Session) bool {
+	if corkable, ok := session.(corkableSession); ok && !corkable.corked() {
+		return true
+	}
+
+	if metered, ok := session.(peakSession); ok {
+		if peak, ok := metered.peak(); ok && peak >= m.deej.config.PlayingPeakThreshold {
+			return true
+		}
+	}
+
+	return false
+}
+
+// playingSessions filters the given sessions down to the ones that are currently playing
+func (m *sessionMap) playingSessions(sessions []Session) []Session {
+	result := []Session{}
+
+	for _, session := range sessions {
+		if m.sessionPlaying(session) {
+			result = append(result, session)
+		}
+	}
+
+	return result
+}
+
+// playingSessionKeys returns the keys of all sessions that are currently playing
+func (m *sessionMap) playingSessionKeys() []string {
+	keys := []string{}
+
+	for _, session := range m.playingSessions(m.allSessions()) {
+		keys = append(keys, session.Key())
+	}
+
+	return funk.UniqString(keys)
+}
@@ -0,0 +1,199 @@
+package deej
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/omriharel/deej/pkg/deej/util"
+	"github.com/thoas/go-funk"
+)
+
+// processIdentity is everything a stream's app can be recognized by. streams only report the binary that
+// opened them, which isn't very helpful under wine (every game is "wine64-preloader"), in flatpaks, or for apps
+// that play audio from a helper process - so /proc is used to find the names users actually know them by
+type processIdentity struct {
+	// what the session is keyed by, e.g. the .exe of a wine game
+	name string
+
+	// other lowercase names the session can be targeted by: the binary itself, its flatpak app id and its parents
+	aliases []string
+}
+
+const (
+	// how far up to look for a helper process' parent, which is usually the app itself
+	maxProcessParentDepth = 3
+
+	// the kernel truncates command names (in /proc/<pid>/stat) to this many characters
+	maxProcessCommLength = 15
+)
+
+var (
+	// flatpak apps run in a systemd scope named after their app id, e.g. "app-flatpak-com.spotify.Client-12345.scope"
+	flatpakScopePattern = regexp.MustCompile(`app-flatpak-(.+)-\d+\.scope`)
+
+	// stream properties holding the flatpak app id of sandboxed clients
+	flatpakAppIDProperties = []string{"pipewire.access.portal.app_id", "application.flatpak.id"}
+
+	// parents that aren't part of the app, even if they share its process group
+	processParentStopNames = []string{"systemd", "init", "sh", "bash", "zsh", "fish", "dash", "flatpak-session-helper", "bwrap"}
+)
+
+// resolveProcessIdentity looks up a stream's process in /proc, starting from the binary name it reported.
+// this is best-effort: the process may be gone already, or not visible to us (e.g. it's on another host)
+func resolveProcessIdentity(props map[string]string, binary string) processIdentity {
+	identity := processIdentity{name: binary}
+
+	aliases := []string{binary}
+
+	// flatpak streams say which app they are, which is more than their pid can tell us
+	flatpakID, isFlatpak := streamFlatpakID(props)
+	if isFlatpak {
+		aliases = append(aliases, flatpakID)
+	}
+
+	if host, ok := props["application.process.host"]; ok {
+		if localHost, err := os.Hostname(); err == nil && host != localHost {
+			return identity.withAliases(aliases)
+		}
+	}
+
+	pid, ok := streamProcessID(props, isFlatpak)
+	if !ok {
+		return identity.withAliases(aliases)
+	}
+
+	names := util.ProcessNames(pid)
+
+	// a windows executable is what the app is known as on windows, so it makes for a portable key
+	if len(names) > 0 && strings.HasSuffix(strings.ToLower(names[0]), ".exe") {
+		identity.name = names[0]
+	}
+
+	aliases = append(aliases, names...)
+
+	if flatpakID, ok := processFlatpakID(pid); ok {
+		aliases = append(aliases, flatpakID)
+	}
+
+	aliases = append(aliases, processParentNames(pid)...)
+
+	return identity.withAliases(aliases)
+}
+
+// withAliases returns the identity with the given (lowercased and deduplicated) aliases, leaving out its own name
+func (identity processIdentity) withAliases(aliases []string) processIdentity {
+	identity.aliases = []string{}
+
+	for _, alias := range aliases {
+		alias = strings.ToLower(alias)
+
+		if alias != "" && alias != strings.ToLower(identity.name) {
+			identity.aliases = append(identity.aliases, alias)
+		}
+	}
+
+	identity.aliases = funk.UniqString(identity.aliases)
+
+	return identity
+}
+
+// streamFlatpakID returns the flatpak app id a stream reports (through the portal), if it comes from one
+func streamFlatpakID(props map[string]string) (string, bool) {
+	for _, key := range flatpakAppIDProperties {
+		if appID, ok := props[key]; ok && appID != "" {
+			return appID, true
+		}
+	}
+
+	return "", false
+}
+
+// streamProcessID returns the pid of a stream's process as we see it, if it can be trusted.
+// clients report their own pid, which is meaningless to us when they're in another pid namespace (like a flatpak's
+// sandbox) - and might even belong to an unrelated process of ours. pipewire's own record of the pid comes from the
+// socket's credentials, which the kernel translates to our namespace, so that one's always good
+func streamProcessID(props map[string]string, isFlatpak bool) (int, bool) {
+	if pid, err := strconv.Atoi(props["pipewire.sec.pid"]); err == nil && pid > 1 {
+		return pid, true
+	}
+
+	if isFlatpak {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(props["application.process.id"])
+	if err != nil || pid <= 1 {
+		return 0, false
+	}
+
+	// make sure the pid belongs to the binary the stream says it does, otherwise it's from another namespace
+	binary, ok := props["application.process.binary"]
+	if !ok || !processRunsBinary(pid, binary) {
+		return 0, false
+	}
+
+	return pid, true
+}
+
+// processRunsBinary returns true if the process' executable is the given binary
+func processRunsBinary(pid int, binary string) bool {
+	names := util.ProcessNames(pid)
+	if len(names) == 0 {
+		return false
+	}
+
+	// the executable's name comes last, after a wine process' .exe
+	exeName := names[len(names)-1]
+	if exeName == binary {
+		return true
+	}
+
+	// when we can only see a process' command name, it's cut short
+	return len(exeName) == maxProcessCommLength && strings.HasPrefix(binary, exeName)
+}
+
+// processFlatpakID returns the app id of a process running inside a flatpak
+func processFlatpakID(pid int) (string, bool) {
+	cgroup, err := util.ReadProcessFile(pid, "cgroup")
+	if err != nil {
+		return "", false
+	}
+
+	match := flatpakScopePattern.FindStringSubmatch(string(cgroup))
+	if match == nil {
+		return "", false
+	}
+
+	return match[1], true
+}
+
+// processParentNames returns the names of the process' parents, for as long as they're part of the same app.
+// an app and its helpers share a process group, unlike the shell or desktop that started the app
+func processParentNames(pid int) []string {
+	names := []string{}
+
+	stat, ok := util.ReadProcessStat(pid)
+	if !ok {
+		return names
+	}
+
+	processGroup := stat.ProcessGroup
+
+	for depth := 0; depth < maxProcessParentDepth; depth++ {
+		if stat.ParentPid <= 1 {
+			break
+		}
+
+		parentStat, ok := util.ReadProcessStat(stat.ParentPid)
+		if !ok || parentStat.ProcessGroup != processGroup || funk.ContainsString(processParentStopNames, parentStat.Comm) {
+			break
+		}
+
+		names = append(names, util.ProcessNames(stat.ParentPid)...)
+		stat = parentStat
+	}
+
+	return names
+}
@@ -0,0 +1,86 @@
+package deej
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestStreamProcessID(t *testing.T) {
+	self := strconv.Itoa(os.Getpid())
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	binary := filepath.Base(exe)
+
+	tests := []struct {
+		name      string
+		props     map[string]string
+		isFlatpak bool
+		want      int
+		ok        bool
+	}{
+		{
+			name:  "pid matching its binary",
+			props: map[string]string{"application.process.id": self, "application.process.binary": binary},
+			want:  os.Getpid(),
+			ok:    true,
+		},
+		{
+			name:  "pid of another binary, i.e. from another namespace",
+			props: map[string]string{"application.process.id": self, "application.process.binary": "firefox"},
+		},
+		{
+			name:  "pid without a binary to check it against",
+			props: map[string]string{"application.process.id": self},
+		},
+		{
+			name:      "flatpak pid",
+			props:     map[string]string{"application.process.id": self, "application.process.binary": binary},
+			isFlatpak: true,
+		},
+		{
+			name:      "pipewire's pid is trusted, even for flatpaks",
+			props:     map[string]string{"application.process.id": "2", "pipewire.sec.pid": self},
+			isFlatpak: true,
+			want:      os.Getpid(),
+			ok:        true,
+		},
+		{
+			name:  "init",
+			props: map[string]string{"application.process.id": "1", "application.process.binary": "systemd"},
+		},
+		{
+			name: "no pid",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := streamProcessID(test.props, test.isFlatpak)
+			if ok != test.ok || got != test.want {
+				t.Fatalf("got %d (%v), want %d (%v)", got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestResolveProcessIdentityFlatpak(t *testing.T) {
+	props := map[string]string{
+		"application.process.id":        "2",
+		"application.process.binary":    "spotify",
+		"pipewire.access.portal.app_id": "com.spotify.Client",
+	}
+
+	identity := resolveProcessIdentity(props, "spotify")
+
+	want := processIdentity{name: "spotify", aliases: []string{"com.spotify.client"}}
+	if !reflect.DeepEqual(identity, want) {
+		t.Fatalf("got %+v, want %+v", identity, want)
+	}
+}
@@ -0,0 +1,248 @@
+package deej
+
+import (
+	"strings"
+)
+
+// propertyTarget matches sessions by one of their stream properties rather than their process name, e.g.
+// "prop:application.name=firefox" (exact match) or "prop:media.name~=youtube" (substring match).
+// properties are only available on Linux, and are compared case-insensitively
+type propertyTarget struct {
+	key      string
+	value    string
+	contains bool
+}
+
+// propertyIndex keeps sessions by their stream properties, so that "prop:" targets don't have to look at every session.
+// only the property keys that some target actually uses are indexed, which leaves out most of a stream's properties
+type propertyIndex struct {
+	keys map[string]bool
+
+	// maps property keys to lowercase property values to the sessions that have them
+	values map[string]map[string][]Session
+
+	// what each session was indexed under, since its properties might've changed by the time it's unindexed
+	entries map[Session][]propertyIndexEntry
+}
+
+type propertyIndexEntry struct {
+	key   string
+	value string
+}
+
+const (
+	propertyTargetPrefix = "prop:"
+
+	propertyTargetContainsOperator = "~="
+	propertyTargetEqualsOperator   = "="
+)
+
+// parsePropertyTarget reads a "prop:" target, returning false if the target isn't one
+func parsePropertyTarget(target string) (*propertyTarget, bool) {
+	target = strings.ToLower(target)
+
+	if !strings.HasPrefix(target, propertyTargetPrefix) {
+		return nil, false
+	}
+
+	expression := strings.TrimPrefix(target, propertyTargetPrefix)
+
+	if parts := strings.SplitN(expression, propertyTargetContainsOperator, 2); len(parts) == 2 {
+		return &propertyTarget{key: strings.TrimSpace(parts[0]), value: strings.TrimSpace(parts[1]), contains: true}, true
+	}
+
+	if parts := strings.SplitN(expression, propertyTargetEqualsOperator, 2); len(parts) == 2 {
+		return &propertyTarget{key: strings.TrimSpace(parts[0]), value: strings.TrimSpace(parts[1])}, true
+	}
+
+	return nil, false
+}
+
+func (t *propertyTarget) matches(session Session) bool {
+	propertySession, ok := session.(propertySession)
+	if !ok {
+		return false
+	}
+
+	value, ok := propertySession.properties()[t.key]
+	if !ok {
+		return false
+	}
+
+	return t.matchesValue(strings.ToLower(value))
+}
+
+func (t *propertyTarget) matchesValue(value string) bool {
+	if t.contains {
+		return strings.Contains(value, t.value)
+	}
+
+	return value == t.value
+}
+
+func newPropertyIndex(keys map[string]bool) *propertyIndex {
+	return &propertyIndex{
+		keys:    keys,
+		values:  make(map[string]map[string][]Session),
+		entries: make(map[Session][]propertyIndexEntry),
+	}
+}
+
+// propertyTargetKeys returns the property keys used by any of the given targets
+func propertyTargetKeys(targets []string) map[string]bool {
+	keys := make(map[string]bool)
+
+	for _, target := range targets {
+		if innerTarget, ok := parseActiveTarget(target); ok {
+			target = innerTarget
+		}
+
+		if propertyTarget, ok := parsePropertyTarget(target); ok {
+			keys[propertyTarget.key] = true
+		}
+	}
+
+	return keys
+}
+
+// index adds a session under each of its indexed properties. the session map's lock must be held by the caller
+func (index *propertyIndex) index(session Session) {
+	propertySession, ok := session.(propertySession)
+	if !ok || len(index.keys) == 0 {
+		return
+	}
+
+	properties := propertySession.properties()
+
+	for key := range index.keys {
+		value, ok := properties[key]
+		if !ok {
+			continue
+		}
+
+		values, ok := index.values[key]
+		if !ok {
+			values = make(map[string][]Session)
+			index.values[key] = values
+		}
+
+		value = strings.ToLower(value)
+		values[value] = append(values[value], session)
+
+		index.entries[session] = append(index.entries[session], propertyIndexEntry{key, value})
+	}
+}
+
+// unindex removes a session from the index. the session map's lock must be held by the caller
+func (index *propertyIndex) unindex(session Session) {
+	for _, entry := range index.entries[session] {
+		values := index.values[entry.key]
+
+		remaining := []Session{}
+		for _, indexed := range values[entry.value] {
+			if indexed != session {
+				remaining = append(remaining, indexed)
+			}
+		}
+
+		if len(remaining) > 0 {
+			values[entry.value] = remaining
+		} else {
+			delete(values, entry.value)
+		}
+
+		if len(values) == 0 {
+			delete(index.values, entry.key)
+		}
+	}
+
+	delete(index.entries, session)
+}
+
+// propertyTargetSessions returns every session matching a property target, using the index
+func (m *sessionMap) propertyTargetSessions(target *propertyTarget) []Session {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	values, ok := m.propertyIndex.values[target.key]
+	if !ok {
+		return []Session{}
+	}
+
+	// exact matches are a single lookup, and substring matches only need to look at this property's values
+	if !target.contains {
+		return append([]Session{}, values[target.value]...)
+	}
+
+	result := []Session{}
+	for value, sessions := range values {
+		if target.matchesValue(value) {
+			result = append(result, sessions...)
+		}
+	}
+
+	return result
+}
+
+// reindexSession updates a session's place in the property index after its properties changed.
+// if that made it match another slider's "prop:" targets (e.g. a browser stream whose media.name now mentions
+// youtube), it gets that slider's volume right away instead of on the slider's next move
+func (m *sessionMap) reindexSession(session Session) {
+	previousSliders := m.propertyTargetSliders(session)
+
+	m.lock.Lock()
+	m.propertyIndex.unindex(session)
+	m.propertyIndex.index(session)
+	m.lock.Unlock()
+
+	for sliderIdx := range m.propertyTargetSliders(session) {
+		if previousSliders[sliderIdx] {
+			continue
+		}
+
+		if value, ok := m.getSliderValue(sliderIdx); ok {
+			m.logger.Debugw("Session now matches slider's property target, applying its value",
+				"session", session,
+				"sliderIdx", sliderIdx)
+
+			m.adjustSlider(sliderIdx, value, false)
+		}
+	}
+}
+
+// propertyTargetSliders returns the sliders with a "prop:" target that the session is indexed under
+func (m *sessionMap) propertyTargetSliders(session Session) map[int]bool {
+	sliders := make(map[int]bool)
+
+	m.deej.config.SliderMapping().iterate(func(sliderIdx int, targets []string) {
+		for _, target := range targets {
+			propertyTarget, ok := parsePropertyTarget(target)
+			if !ok {
+				continue
+			}
+
+			for _, matchingSession := range m.propertyTargetSessions(propertyTarget) {
+				if matchingSession == session {
+					sliders[sliderIdx] = true
+					return
+				}
+			}
+		}
+	})
+
+	return sliders
+}
+
+// rebuildPropertyIndex indexes every session again, for when the config's property targets change
+func (m *sessionMap) rebuildPropertyIndex() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.propertyIndex = newPropertyIndex(m.deej.config.PropertyTargetKeys)
+
+	for _, sessions := range m.m {
+		for _, session := range sessions {
+			m.propertyIndex.index(session)
+		}
+	}
+}
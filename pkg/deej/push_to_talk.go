@@ -0,0 +1,273 @@
+package deej
+
+import (
+	"sync"
+	"time"
+)
+
+// pushToTalkState turns a hardware button into a press-and-hold control for the microphone.
+// the firmware doesn't report releases: a click is a single mute toggle, and a held button repeats
+// the toggle in every frame once it's been down for a moment. so a press is a burst of toggles, and it's
+// over once they stop coming for pushToTalkRepeatTimeout. two clicks (presses without any repeats) within
+// the double-press window latch the mic in its held state, until the end of the next press
+type pushToTalkState struct {
+	// whether the mic is (logically) held, which is what decides its mute state
+	held bool
+
+	// a press is in progress, and when it started and last repeated
+	pressing   bool
+	pressStart time.Time
+	lastRepeat time.Time
+
+	// the mic stays held after a double-press, and the press that ends the latch is marked as unlatching
+	latched    bool
+	unlatching bool
+
+	// when the last click started, to tell a double-press from two separate ones (zero if there isn't one)
+	lastClick time.Time
+
+	// when the release tail ends and the mic goes back to idle (zero if it isn't pending)
+	releaseAt time.Time
+
+	// copied from the config on every press
+	releaseTail       time.Duration
+	doublePressWindow time.Duration
+
+	// fires at the next deadline (a press ending or a tail running out)
+	timer *time.Timer
+
+	lock sync.Locker
+}
+
+const (
+	// the mic is muted, except while the button is held
+	pushToTalkModeTalk = "talk"
+
+	// the mic is open, except while the button is held
+	pushToTalkModeMute = "mute"
+
+	// a held button repeats its toggle in every frame, which comes in many times faster than this.
+	// a gap this long means the button was let go
+	pushToTalkRepeatTimeout = 150 * time.Millisecond
+)
+
+func newPushToTalkState() *pushToTalkState {
+	return &pushToTalkState{
+		lock: &sync.Mutex{},
+	}
+}
+
+// press records a toggle from the button, returning true if the mic's held state changed.
+// the caller must hold the lock
+func (s *pushToTalkState) press(now time.Time) bool {
+
+	// another repeat of the press that's already going on
+	if s.pressing {
+		s.lastRepeat = now
+		return false
+	}
+
+	s.pressing = true
+	s.pressStart = now
+	s.lastRepeat = now
+
+	// pressing again cancels a pending release tail
+	s.releaseAt = time.Time{}
+
+	if s.latched {
+		s.latched = false
+		s.unlatching = true
+	}
+
+	changed := !s.held
+	s.held = true
+
+	return changed
+}
+
+// advance handles everything that's due by now: a press ending, and its release tail running out.
+// it returns true if the mic's held state changed. the caller must hold the lock
+func (s *pushToTalkState) advance(now time.Time) bool {
+	if s.pressing && !now.Before(s.pressEnd()) {
+		s.endPress()
+	}
+
+	if !s.releaseAt.IsZero() && !now.Before(s.releaseAt) {
+		s.releaseAt = time.Time{}
+
+		changed := s.held
+		s.held = false
+
+		return changed
+	}
+
+	return false
+}
+
+func (s *pushToTalkState) endPress() {
+	pressEnd := s.pressEnd()
+	click := s.lastRepeat.Equal(s.pressStart)
+
+	s.pressing = false
+
+	// the press that ends a latch releases the mic like any other, and doesn't count as a click
+	if s.unlatching {
+		s.unlatching = false
+		s.lastClick = time.Time{}
+		s.releaseAt = pressEnd.Add(s.releaseTail)
+
+		return
+	}
+
+	if click && !s.lastClick.IsZero() && !s.pressStart.After(s.lastClick.Add(s.doublePressWindow)) {
+		s.latched = true
+		s.lastClick = time.Time{}
+
+		return
+	}
+
+	if click {
+		s.lastClick = s.pressStart
+	} else {
+		s.lastClick = time.Time{}
+	}
+
+	// keep the mic in its held state for a little while longer, unless the button is pressed again
+	s.releaseAt = pressEnd.Add(s.releaseTail)
+}
+
+// pressEnd is when the current press counts as over, if it doesn't repeat before then
+func (s *pushToTalkState) pressEnd() time.Time {
+	return s.lastRepeat.Add(pushToTalkRepeatTimeout)
+}
+
+// nextDeadline returns the next time advance has something to do, or false if nothing's pending
+func (s *pushToTalkState) nextDeadline() (time.Time, bool) {
+	if s.pressing {
+		return s.pressEnd(), true
+	}
+
+	if !s.releaseAt.IsZero() {
+		return s.releaseAt, true
+	}
+
+	return time.Time{}, false
+}
+
+func (m *sessionMap) pushToTalkEnabled() bool {
+	return m.deej.config.PushToTalk.Button >= 0
+}
+
+// initializePushToTalk puts the mic in its idle state, i.e. muted for push-to-talk
+func (m *sessionMap) initializePushToTalk() {
+	if !m.pushToTalkEnabled() {
+		return
+	}
+
+	m.logger.Infow("Push-to-talk configured, setting mic to its idle state",
+		"mode", m.deej.config.PushToTalk.Mode,
+		"button", m.deej.config.PushToTalk.Button)
+
+	m.setPushToTalkMic(false)
+}
+
+// handlePushToTalkButton intercepts presses of the configured push-to-talk button, using them to drive the mic
+func (m *sessionMap) handlePushToTalkButton(event SliderEvent) SliderEvent {
+	if !event.ToggleMute || !m.pushToTalkEnabled() || event.SliderID != m.deej.config.PushToTalk.Button {
+		return event
+	}
+
+	event.ToggleMute = false
+
+	m.pushToTalk.lock.Lock()
+	defer m.pushToTalk.lock.Unlock()
+
+	m.pushToTalk.releaseTail = m.deej.config.PushToTalk.ReleaseTail
+	m.pushToTalk.doublePressWindow = m.deej.config.PushToTalk.DoublePressWindow
+
+	if m.pushToTalk.press(time.Now()) {
+		m.logger.Debug("Push-to-talk button held")
+		m.setPushToTalkMic(true)
+	}
+
+	m.schedulePushToTalk()
+
+	return event
+}
+
+// schedulePushToTalk sets the timer for the next push-to-talk deadline, if there is one.
+// the push-to-talk lock must be held by the caller
+func (m *sessionMap) schedulePushToTalk() {
+	deadline, ok := m.pushToTalk.nextDeadline()
+	if !ok {
+		return
+	}
+
+	delay := time.Until(deadline)
+
+	if m.pushToTalk.timer == nil {
+		m.pushToTalk.timer = time.AfterFunc(delay, m.advancePushToTalk)
+	} else {
+		m.pushToTalk.timer.Reset(delay)
+	}
+}
+
+func (m *sessionMap) advancePushToTalk() {
+	m.pushToTalk.lock.Lock()
+	defer m.pushToTalk.lock.Unlock()
+
+	wasLatched := m.pushToTalk.latched
+
+	if m.pushToTalk.advance(time.Now()) {
+		m.logger.Debug("Push-to-talk button released")
+		m.setPushToTalkMic(m.pushToTalk.held)
+	}
+
+	if m.pushToTalk.latched && !wasLatched {
+		m.logger.Info("Push-to-talk double-pressed, latching mic")
+	}
+
+	m.schedulePushToTalk()
+}
+
+// applyPushToTalkToNewSession brings a newly added mic session (e.g. after the default source changed)
+// in line with the push-to-talk button's current state
+func (m *sessionMap) applyPushToTalkToNewSession(session Session) {
+	if !m.pushToTalkEnabled() || session.Key() != inputSessionName {
+		return
+	}
+
+	m.pushToTalk.lock.Lock()
+	held := m.pushToTalk.held
+	m.pushToTalk.lock.Unlock()
+
+	m.logger.Debugw("Applying push-to-talk state to new mic session", "held", held)
+
+	if err := session.SetMute(m.pushToTalkMute(held)); err != nil {
+		m.logger.Warnw("Failed to set mic mute for push-to-talk", "error", err)
+	}
+}
+
+// setPushToTalkMic mutes or unmutes the mic according to whether the button is (logically) held and the mode
+func (m *sessionMap) setPushToTalkMic(held bool) {
+	sessions, ok := m.get(inputSessionName)
+	if !ok {
+		m.logger.Warn("Push-to-talk couldn't find the mic session")
+		return
+	}
+
+	for _, session := range sessions {
+		if err := session.SetMute(m.pushToTalkMute(held)); err != nil {
+			m.logger.Warnw("Failed to set mic mute for push-to-talk", "error", err)
+		}
+	}
+}
+
+// pushToTalkMute returns whether the mic should be muted, given the button's (logical) held state
+func (m *sessionMap) pushToTalkMute(held bool) bool {
+	if m.deej.config.PushToTalk.Mode == pushToTalkModeMute {
+		return held
+	}
+
+	return !held
+}
@@ -0,0 +1,126 @@
+package deej
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPushToTalkState(t *testing.T) {
+	const (
+		releaseTail       = 250 * time.Millisecond
+		doublePressWindow = 400 * time.Millisecond
+		ms                = time.Millisecond
+	)
+
+	type step struct {
+		at    time.Duration
+		press bool // a toggle from the button, otherwise just time passing
+
+		held    bool
+		latched bool
+	}
+
+	// a held button repeats every frame, every 20ms is plenty
+	hold := func(from time.Duration, until time.Duration) []step {
+		steps := []step{}
+		for at := from; at <= until; at += 20 * ms {
+			steps = append(steps, step{at: at, press: true, held: true})
+		}
+
+		return steps
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "press",
+			steps: []step{
+				{at: 0, held: false},
+				{at: 10 * ms, press: true, held: true},
+				{at: 100 * ms, held: true},
+			},
+		},
+		{
+			name: "click releases after the repeat timeout and tail",
+			steps: []step{
+				{at: 0, press: true, held: true},
+				{at: pushToTalkRepeatTimeout, held: true},
+				{at: pushToTalkRepeatTimeout + releaseTail - ms, held: true},
+				{at: pushToTalkRepeatTimeout + releaseTail, held: false},
+			},
+		},
+		{
+			name: "hold stays held while repeating",
+			steps: append(hold(0, 2*time.Second),
+				step{at: 2*time.Second + pushToTalkRepeatTimeout - ms, held: true},
+				step{at: 2*time.Second + pushToTalkRepeatTimeout + releaseTail - ms, held: true},
+				step{at: 2*time.Second + pushToTalkRepeatTimeout + releaseTail, held: false},
+			),
+		},
+		{
+			name: "release tail is cancelled by another press",
+			steps: append(append(hold(0, time.Second),
+				step{at: time.Second + pushToTalkRepeatTimeout + 100*ms, held: true}),
+				append(hold(time.Second+pushToTalkRepeatTimeout+200*ms, 2*time.Second),
+					step{at: 2*time.Second + pushToTalkRepeatTimeout + releaseTail, held: false})...,
+			),
+		},
+		{
+			name: "double-press latches until the next press ends",
+			steps: []step{
+				{at: 0, press: true, held: true},
+				{at: 300 * ms, press: true, held: true},
+				{at: 300*ms + pushToTalkRepeatTimeout, held: true, latched: true},
+				{at: time.Minute, held: true, latched: true},
+				{at: time.Minute + 10*ms, press: true, held: true},
+				{at: time.Minute + 10*ms + pushToTalkRepeatTimeout, held: true},
+				{at: time.Minute + 10*ms + pushToTalkRepeatTimeout + releaseTail, held: false},
+			},
+		},
+		{
+			name: "slow double-press doesn't latch",
+			steps: []step{
+				{at: 0, press: true, held: true},
+				{at: pushToTalkRepeatTimeout + releaseTail, held: false},
+				{at: 500 * ms, press: true, held: true},
+				{at: 500*ms + pushToTalkRepeatTimeout + releaseTail, held: false},
+			},
+		},
+		{
+			name: "hold after a click doesn't latch",
+			steps: append(
+				[]step{{at: 0, press: true, held: true}},
+				append(hold(300*ms, time.Second),
+					step{at: time.Second + pushToTalkRepeatTimeout + releaseTail, held: false})...,
+			),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start := time.Now()
+
+			state := newPushToTalkState()
+			state.releaseTail = releaseTail
+			state.doublePressWindow = doublePressWindow
+
+			for idx, step := range test.steps {
+				now := start.Add(step.at)
+
+				// time passes before anything else happens, just like with the real timer
+				state.advance(now)
+
+				if step.press {
+					state.press(now)
+				}
+
+				if state.held != step.held || state.latched != step.latched {
+					t.Fatalf("step %d (at %v): got held=%v latched=%v, want held=%v latched=%v",
+						idx, step.at, state.held, state.latched, step.held, step.latched)
+				}
+			}
+		})
+	}
+}
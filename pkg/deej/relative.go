@@ -0,0 +1,70 @@
+package deej
+
+import "strings"
+
+// masterSliderIdx returns the index of the slider mapped to master, or -1 if there isn't one
+func (m *sessionMap) masterSliderIdx() int {
+	masterIdx := -1
+
+	m.deej.config.SliderMapping().iterate(func(sliderIdx int, targets []string) {
+		for _, target := range targets {
+			if strings.ToLower(target) == masterSessionName && (masterIdx == -1 || sliderIdx < masterIdx) {
+				masterIdx = sliderIdx
+			}
+		}
+	})
+
+	return masterIdx
+}
+
+// masterSliderValue returns the master slider's last known value. until it moves for the first time,
+// the master session's own volume is used instead
+func (m *sessionMap) masterSliderValue() float32 {
+	if value, ok := m.getSliderValue(m.masterSliderIdx()); ok {
+		return value
+	}
+
+	if sessions, ok := m.get(masterSessionName); ok {
+		return sessions[0].GetVolume()
+	}
+
+	return 1
+}
+
+// reapplyRelativeSliders recomputes the volume of every target that's relative to master
+func (m *sessionMap) reapplyRelativeSliders() {
+	relativeSliders := []int{}
+
+	sliderMapping := m.deej.config.SliderMapping()
+
+	sliderMapping.iterate(func(sliderIdx int, _ []string) {
+		if options, ok := sliderMapping.options[sliderIdx]; ok && options.relative {
+			relativeSliders = append(relativeSliders, sliderIdx)
+		}
+	})
+
+	for _, sliderIdx := range relativeSliders {
+		value, ok := m.getSliderValue(sliderIdx)
+		if !ok {
+			continue
+		}
+
+		m.logger.Debugw("Master moved, re-applying relative slider", "sliderIdx", sliderIdx)
+		m.adjustSlider(sliderIdx, value, false)
+	}
+}
+
+func (m *sessionMap) getSliderValue(sliderIdx int) (float32, bool) {
+	m.sliderValuesLock.Lock()
+	defer m.sliderValuesLock.Unlock()
+
+	value, ok := m.sliderValues[sliderIdx]
+	return value, ok
+}
+
+func (m *sessionMap) setSliderValue(sliderIdx int, value float32) {
+	m.sliderValuesLock.Lock()
+	defer m.sliderValuesLock.Unlock()
+
+	m.sliderValues[sliderIdx] = value
+}
@@ -0,0 +1,261 @@
+package deej
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// rememberedVolumes keeps the last slider value of each target belonging to a slider with the
+// "remember" option, and persists them to the internal config so they survive restarts.
+// targets are kept lowercase, like slider targets are matched
+type rememberedVolumes struct {
+	m    map[string]float32
+	lock sync.Locker
+
+	// slider moves come in bursts, so writes to disk are delayed and batched
+	saveTimer *time.Timer
+}
+
+const (
+	// these are saved as a list rather than a map keyed by target: viper splits keys on dots (as in "app.exe"),
+	// and lowercases them when reading them back
+	configKeyRememberedVolumes = "remembered_volumes"
+	rememberedVolumeKeyTarget  = "target"
+	rememberedVolumeKeyVolume  = "volume"
+
+	rememberedVolumesSaveDelay = 5 * time.Second
+)
+
+func newRememberedVolumes() *rememberedVolumes {
+	return &rememberedVolumes{
+		m:    make(map[string]float32),
+		lock: &sync.Mutex{},
+	}
+}
+
+// loadRememberedVolumes reads previously remembered volumes from the internal config. the in-memory values
+// are the most recent ones, so this only happens the first time the config is loaded
+func (cc *CanonicalConfig) loadRememberedVolumes() {
+	cc.rememberedVolumes.lock.Lock()
+	defer cc.rememberedVolumes.lock.Unlock()
+
+	if len(cc.rememberedVolumes.m) > 0 {
+		return
+	}
+
+	cc.internalConfigLock.Lock()
+	remembered := cast.ToSlice(cc.internalConfig.Get(configKeyRememberedVolumes))
+	cc.internalConfigLock.Unlock()
+
+	for _, entry := range remembered {
+		entryMap := cast.ToStringMap(entry)
+
+		target := strings.ToLower(cast.ToString(entryMap[rememberedVolumeKeyTarget]))
+		if target == "" {
+			continue
+		}
+
+		cc.rememberedVolumes.m[target] = cast.ToFloat32(entryMap[rememberedVolumeKeyVolume])
+	}
+
+	cc.logger.Debugw("Loaded remembered volumes", "count", len(cc.rememberedVolumes.m))
+}
+
+// RememberedVolume returns the last slider value remembered for the given target, if any
+func (cc *CanonicalConfig) RememberedVolume(target string) (float32, bool) {
+	cc.rememberedVolumes.lock.Lock()
+	defer cc.rememberedVolumes.lock.Unlock()
+
+	value, ok := cc.rememberedVolumes.m[strings.ToLower(target)]
+	return value, ok
+}
+
+// RememberVolume records a target's slider value, and schedules it to be saved to the internal config
+func (cc *CanonicalConfig) RememberVolume(target string, value float32) {
+	target = strings.ToLower(target)
+
+	cc.rememberedVolumes.lock.Lock()
+	defer cc.rememberedVolumes.lock.Unlock()
+
+	if previous, ok := cc.rememberedVolumes.m[target]; ok && previous == value {
+		return
+	}
+
+	cc.rememberedVolumes.m[target] = value
+
+	if cc.rememberedVolumes.saveTimer == nil {
+		cc.rememberedVolumes.saveTimer = time.AfterFunc(rememberedVolumesSaveDelay, cc.saveRememberedVolumes)
+	}
+}
+
+// flushRememberedVolumes saves remembered volumes right away if a save is pending, e.g. when deej is stopping
+func (cc *CanonicalConfig) flushRememberedVolumes() {
+	cc.rememberedVolumes.lock.Lock()
+	pending := cc.rememberedVolumes.saveTimer != nil && cc.rememberedVolumes.saveTimer.Stop()
+	cc.rememberedVolumes.lock.Unlock()
+
+	if pending {
+		cc.saveRememberedVolumes()
+	}
+}
+
+func (cc *CanonicalConfig) saveRememberedVolumes() {
+	cc.rememberedVolumes.lock.Lock()
+
+	volumes := make([]map[string]interface{}, 0, len(cc.rememberedVolumes.m))
+	for target, value := range cc.rememberedVolumes.m {
+		volumes = append(volumes, map[string]interface{}{
+			rememberedVolumeKeyTarget: target,
+			rememberedVolumeKeyVolume: value,
+		})
+	}
+
+	// keep the file stable between saves
+	sort.Slice(volumes, func(i, j int) bool {
+		return volumes[i][rememberedVolumeKeyTarget].(string) < volumes[j][rememberedVolumeKeyTarget].(string)
+	})
+
+	cc.rememberedVolumes.saveTimer = nil
+	cc.rememberedVolumes.lock.Unlock()
+
+	// this runs on the save timer's goroutine, while the config might be reloading
+	cc.internalConfigLock.Lock()
+	defer cc.internalConfigLock.Unlock()
+
+	cc.internalConfig.Set(configKeyRememberedVolumes, volumes)
+
+	if err := cc.internalConfig.WriteConfigAs(path.Join(internalConfigPath, internalConfigFilepath)); err != nil {
+		cc.logger.Warnw("Failed to save remembered volumes to internal config", "error", err)
+		return
+	}
+
+	cc.logger.Debugw("Saved remembered volumes to internal config", "count", len(volumes))
+}
+
+// rememberSliderValue records a slider's value for each of its targets, if it's set to remember them
+func (m *sessionMap) rememberSliderValue(sliderIdx int, value float32) {
+	sliderMapping := m.deej.config.SliderMapping()
+
+	options, ok := sliderMapping.getOptions(sliderIdx)
+	if !ok || options == nil || !options.remember {
+		return
+	}
+
+	targets, _ := sliderMapping.get(sliderIdx)
+	for _, target := range targets {
+		m.deej.config.RememberVolume(target, value)
+	}
+}
+
+// applyRememberedVolumes sets newly discovered sessions belonging to remembering sliders to the slider's
+// current value, or to the value remembered for them if the slider hasn't reported one yet (e.g. right after
+// starting). this way, apps that relaunch don't have to wait for the slider to move again.
+// sessions that were already around are left alone, they got their volume when the slider last moved
+func (m *sessionMap) applyRememberedVolumes(sessions []Session) {
+	if len(sessions) == 0 {
+		return
+	}
+
+	discovered := make(map[Session]bool, len(sessions))
+	for _, session := range sessions {
+		discovered[session] = true
+	}
+
+	type rememberedSlider struct {
+		sliderIdx int
+		value     float32
+	}
+
+	sliders := []rememberedSlider{}
+
+	sliderMapping := m.deej.config.SliderMapping()
+
+	sliderMapping.iterate(func(sliderIdx int, targets []string) {
+		options := sliderMapping.options[sliderIdx]
+		if options == nil || !options.remember {
+			return
+		}
+
+		if value, ok := m.getSliderValue(sliderIdx); ok {
+			sliders = append(sliders, rememberedSlider{sliderIdx, value})
+			return
+		}
+
+		// all of a slider's targets share its value, so the first one that was remembered will do
+		for _, target := range targets {
+			if value, ok := m.deej.config.RememberedVolume(target); ok {
+				sliders = append(sliders, rememberedSlider{sliderIdx, value})
+				return
+			}
+		}
+	})
+
+	for _, slider := range sliders {
+		for target, volume := range m.sliderTargetVolumes(sliderMapping, slider.sliderIdx, slider.value) {
+			options := sliderMapping.options[slider.sliderIdx]
+
+			for _, session := range m.sliderTargetSessions(options, target) {
+				if !discovered[session] {
+					continue
+				}
+
+				m.logger.Debugw("Applying remembered slider value",
+					"sliderIdx", slider.sliderIdx,
+					"value", slider.value,
+					"session", session)
+
+				if err := session.SetVolume(m.scheduledVolume(session, volume)); err != nil {
+					m.logger.Warnw("Failed to set remembered session volume", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// sliderTargetVolumes returns the volume each of a slider's targets would get from the given slider value,
+// the same way adjustSlider hands them out
+func (m *sessionMap) sliderTargetVolumes(sliderMapping *sliderMap, sliderIdx int, value float32) map[string]float32 {
+	volumes := make(map[string]float32)
+
+	targets, ok := sliderMapping.get(sliderIdx)
+	if !ok {
+		return volumes
+	}
+
+	options, _ := sliderMapping.getOptions(sliderIdx)
+
+	if options != nil && options.relative && sliderIdx != m.masterSliderIdx() {
+		value *= m.masterSliderValue()
+	}
+
+	setTargets := func(targets []string, volume float32) {
+		for _, target := range targets {
+			targetVolume := volume
+			if modifier, ok := options.modifier(target); ok {
+				targetVolume = modifier.apply(volume)
+			}
+
+			volumes[target] = targetVolume
+		}
+	}
+
+	if options != nil && options.crossfade != nil {
+		gainA, gainB := options.crossfade.gains(value)
+
+		setTargets(options.crossfade.groupA, gainA)
+		setTargets(options.crossfade.groupB, gainB)
+	} else if options != nil && options.fallback {
+		if target, ok := m.firstAvailableTarget(options, targets); ok {
+			setTargets([]string{target}, value)
+		}
+	} else {
+		setTargets(targets, value)
+	}
+
+	return volumes
+}
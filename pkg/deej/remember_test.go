@@ -0,0 +1,51 @@
+package deej
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// newTestRememberingConfig returns a config that keeps its internal config in the given directory
+func newTestRememberingConfig(dir string) *CanonicalConfig {
+	internalConfig := viper.New()
+	internalConfig.SetConfigName(internalConfigName)
+	internalConfig.SetConfigType(configType)
+	internalConfig.AddConfigPath(dir)
+
+	return &CanonicalConfig{
+		logger:             zap.NewNop().Sugar(),
+		internalConfig:     internalConfig,
+		internalConfigLock: &sync.Mutex{},
+		rememberedVolumes:  newRememberedVolumes(),
+	}
+}
+
+func TestRememberedVolumesSurviveRestart(t *testing.T) {
+	previousPath := internalConfigPath
+	internalConfigPath = t.TempDir()
+	defer func() { internalConfigPath = previousPath }()
+
+	const target = "UnrealEditor-Win64-DebugGame.exe"
+
+	cc := newTestRememberingConfig(internalConfigPath)
+	cc.RememberVolume(target, 0.4)
+	cc.flushRememberedVolumes()
+
+	// as if deej was started again
+	reloaded := newTestRememberingConfig(internalConfigPath)
+	if err := reloaded.internalConfig.ReadInConfig(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reloaded.loadRememberedVolumes()
+
+	for _, lookup := range []string{target, "unrealeditor-win64-debuggame.exe"} {
+		value, ok := reloaded.RememberedVolume(lookup)
+		if !ok || !floatsNear(value, 0.4) {
+			t.Fatalf("expected %q to be remembered as 0.4 after reloading, got %v (%t)", lookup, value, ok)
+		}
+	}
+}
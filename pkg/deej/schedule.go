@@ -0,0 +1,423 @@
+package deej
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// schedule is a recurring time window during which volume policies apply: caps (maximum volumes),
+// fixed levels (volumes that sliders can't change) and/or a profile that overrides some slider mappings.
+// a window is either given as weekdays with a start and end time, or as a cron expression and a duration
+type schedule struct {
+	name string
+
+	// weekday/time windows - an end time earlier than the start time means the window ends on the next day,
+	// and the same time for both means the whole day
+	days map[time.Weekday]bool
+	from int // minutes since midnight
+	to   int
+
+	// cron-like windows start whenever the expression matches, and last for the duration
+	cron     *cronExpression
+	duration time.Duration
+
+	caps    map[string]float32
+	levels  map[string]float32
+	profile string
+}
+
+// cronExpression is a standard 5-field cron expression (minute, hour, day of month, month, day of week)
+type cronExpression struct {
+	minutes     map[int]bool
+	hours       map[int]bool
+	daysOfMonth map[int]bool
+	months      map[int]bool
+	daysOfWeek  map[int]bool
+
+	// like in cron, when both day fields are restricted (i.e. not "*"), a day matching either of them will do
+	daysOfMonthRestricted bool
+	daysOfWeekRestricted  bool
+}
+
+const (
+	scheduleKeyName     = "name"
+	scheduleKeyDays     = "days"
+	scheduleKeyFrom     = "from"
+	scheduleKeyTo       = "to"
+	scheduleKeyCron     = "cron"
+	scheduleKeyDuration = "duration"
+	scheduleKeyCaps     = "caps"
+	scheduleKeyLevels   = "levels"
+	scheduleKeyProfile  = "profile"
+
+	// how often the scheduler checks whether a window started or ended
+	scheduleEvaluationInterval = 15 * time.Second
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func schedulesFromConfig(value interface{}) ([]*schedule, error) {
+	schedules := []*schedule{}
+
+	for scheduleIdx, item := range cast.ToSlice(value) {
+		scheduleMap := cast.ToStringMap(item)
+
+		s := &schedule{
+			name:    cast.ToString(scheduleMap[scheduleKeyName]),
+			caps:    volumesFromConfigValue(scheduleMap[scheduleKeyCaps]),
+			levels:  volumesFromConfigValue(scheduleMap[scheduleKeyLevels]),
+			profile: strings.ToLower(cast.ToString(scheduleMap[scheduleKeyProfile])),
+		}
+
+		if s.name == "" {
+			s.name = fmt.Sprintf("schedule %d", scheduleIdx)
+		}
+
+		if cronString := cast.ToString(scheduleMap[scheduleKeyCron]); cronString != "" {
+			cron, err := parseCronExpression(cronString)
+			if err != nil {
+				return nil, fmt.Errorf("parse cron expression for %s: %w", s.name, err)
+			}
+
+			s.cron = cron
+			s.duration = cast.ToDuration(scheduleMap[scheduleKeyDuration])
+
+			if s.duration <= 0 {
+				return nil, fmt.Errorf("%s has a cron expression, but no positive duration", s.name)
+			}
+		} else {
+			var err error
+
+			if s.days, err = parseWeekdays(scheduleMap[scheduleKeyDays]); err != nil {
+				return nil, fmt.Errorf("parse days for %s: %w", s.name, err)
+			}
+
+			if s.from, err = parseTimeOfDay(cast.ToString(scheduleMap[scheduleKeyFrom])); err != nil {
+				return nil, fmt.Errorf("parse start time for %s: %w", s.name, err)
+			}
+
+			if s.to, err = parseTimeOfDay(cast.ToString(scheduleMap[scheduleKeyTo])); err != nil {
+				return nil, fmt.Errorf("parse end time for %s: %w", s.name, err)
+			}
+		}
+
+		schedules = append(schedules, s)
+	}
+
+	return schedules, nil
+}
+
+// active returns true if the given time falls within this schedule's window
+func (s *schedule) active(now time.Time) bool {
+	if s.cron != nil {
+		// look for a start of the window within the last duration, minute by minute
+		start := now.Truncate(time.Minute)
+		for t := start; !t.Before(start.Add(-s.duration)); t = t.Add(-time.Minute) {
+			if s.cron.matches(t) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	yesterday := now.AddDate(0, 0, -1).Weekday()
+
+	if s.from == s.to {
+		return s.days[now.Weekday()]
+	}
+
+	if s.from < s.to {
+		return s.days[now.Weekday()] && minute >= s.from && minute < s.to
+	}
+
+	// windows that pass midnight belong to the day they started on
+	return (s.days[now.Weekday()] && minute >= s.from) || (s.days[yesterday] && minute < s.to)
+}
+
+func (s *schedule) String() string {
+	return s.name
+}
+
+// volumesFromConfigValue reads a map of targets to volumes (e.g. "master: 0.3"), with lowercase targets
+func volumesFromConfigValue(value interface{}) map[string]float32 {
+	volumes := make(map[string]float32)
+
+	for target, volume := range cast.ToStringMap(value) {
+		volumes[strings.ToLower(target)] = cast.ToFloat32(volume)
+	}
+
+	return volumes
+}
+
+// parseWeekdays reads a list of weekdays (e.g. [mon, wed]) or ranges of them (e.g. mon-fri), defaulting to every day
+func parseWeekdays(value interface{}) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+
+	items := cast.ToStringSlice(value)
+	if len(items) == 0 {
+		items = []string{"sun-sat"}
+	}
+
+	for _, item := range items {
+		bounds := strings.SplitN(strings.ToLower(item), "-", 2)
+
+		first, ok := weekdayNames[bounds[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", bounds[0])
+		}
+
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = weekdayNames[bounds[1]]; !ok {
+				return nil, fmt.Errorf("unknown weekday %q", bounds[1])
+			}
+		}
+
+		for day := first; ; day = (day + 1) % 7 {
+			days[day] = true
+
+			if day == last {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+// parseTimeOfDay reads a 24-hour "hh:mm" time into minutes since midnight
+func parseTimeOfDay(value string) (int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: %w", value, err)
+	}
+
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+func parseCronExpression(expression string) (*cronExpression, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	bounds := [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	sets := make([]map[int]bool, len(fields))
+
+	for fieldIdx, field := range fields {
+		set, err := parseCronField(field, bounds[fieldIdx][0], bounds[fieldIdx][1])
+		if err != nil {
+			return nil, fmt.Errorf("field %d (%q): %w", fieldIdx, field, err)
+		}
+
+		sets[fieldIdx] = set
+	}
+
+	return &cronExpression{
+		minutes:     sets[0],
+		hours:       sets[1],
+		daysOfMonth: sets[2],
+		months:      sets[3],
+		daysOfWeek:  sets[4],
+
+		daysOfMonthRestricted: !strings.HasPrefix(fields[2], "*"),
+		daysOfWeekRestricted:  !strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// parseCronField supports *, single values, ranges (a-b), lists (a,b) and steps (*/n, a-b/n)
+func parseCronField(field string, min int, max int) (map[int]bool, error) {
+	set := make(map[int]bool)
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+
+		if stepIdx := strings.Index(part, "/"); stepIdx != -1 {
+			var err error
+			if step, err = strconv.Atoi(part[stepIdx+1:]); err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+
+			part = part[:stepIdx]
+		}
+
+		low, high := min, max
+
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+
+			var err error
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid value in %q", part)
+			}
+
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid value in %q", part)
+				}
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return nil, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for value := low; value <= high; value += step {
+			set[value] = true
+		}
+	}
+
+	return set, nil
+}
+
+func (c *cronExpression) matches(t time.Time) bool {
+	dayOfMonth := c.daysOfMonth[t.Day()]
+	dayOfWeek := c.daysOfWeek[int(t.Weekday())]
+
+	day := dayOfMonth && dayOfWeek
+	if c.daysOfMonthRestricted && c.daysOfWeekRestricted {
+		day = dayOfMonth || dayOfWeek
+	}
+
+	return c.minutes[t.Minute()] &&
+		c.hours[t.Hour()] &&
+		c.months[int(t.Month())] &&
+		day
+}
+
+// runScheduler keeps track of which schedules are active, applying them as soon as their windows start or end
+func (d *Deej) runScheduler() {
+	logger := d.logger.Named("scheduler")
+	configReloadedChannel := d.config.SubscribeToChanges()
+
+	ticker := time.NewTicker(scheduleEvaluationInterval)
+	defer ticker.Stop()
+
+	lastActive := ""
+
+	evaluate := func(force bool) {
+		active := []*schedule{}
+		for _, s := range d.config.currentSchedules() {
+			if s.active(time.Now()) {
+				active = append(active, s)
+			}
+		}
+
+		activeNames := fmt.Sprintf("%v", active)
+		if activeNames == lastActive && !force {
+			return
+		}
+
+		logger.Infow("Active schedules changed", "from", lastActive, "to", activeNames)
+		lastActive = activeNames
+
+		d.sessions.setActiveSchedules(active)
+	}
+
+	evaluate(false)
+
+	for {
+		select {
+		case <-ticker.C:
+			evaluate(false)
+
+		// schedules (and the profiles they use) may have changed, so always re-apply them
+		case <-configReloadedChannel:
+			evaluate(true)
+		}
+	}
+}
+
+// setActiveSchedules applies a new set of active schedules right away, instead of waiting for the next slider event
+func (m *sessionMap) setActiveSchedules(schedules []*schedule) {
+	m.schedulesLock.Lock()
+	m.activeSchedules = schedules
+	m.schedulesLock.Unlock()
+
+	// the last active schedule with a profile wins
+	profile := ""
+	for _, s := range schedules {
+		if s.profile != "" {
+			profile = s.profile
+		}
+	}
+
+	m.deej.config.setActiveProfile(profile)
+
+	// re-applying every slider takes care of new caps and profiles, as well as lifting caps that ended
+	m.reapplyAllSliders()
+
+	// fixed levels and caps also apply to targets that aren't bound to any slider
+	for _, s := range schedules {
+		for target, level := range s.levels {
+			for _, session := range m.targetSessions(target) {
+				if err := session.SetVolume(level); err != nil {
+					m.logger.Warnw("Failed to apply scheduled volume level", "schedule", s, "target", target, "error", err)
+				}
+			}
+		}
+
+		for target, maxVolume := range s.caps {
+			for _, session := range m.targetSessions(target) {
+				if session.GetVolume() <= maxVolume {
+					continue
+				}
+
+				if err := session.SetVolume(maxVolume); err != nil {
+					m.logger.Warnw("Failed to apply scheduled volume cap", "schedule", s, "target", target, "error", err)
+				}
+			}
+		}
+	}
+}
+
+// scheduledVolume returns the volume a session should get instead of the given one, according to the active schedules
+func (m *sessionMap) scheduledVolume(session Session, volume float32) float32 {
+	m.schedulesLock.Lock()
+	defer m.schedulesLock.Unlock()
+
+	for _, s := range m.activeSchedules {
+		for target, level := range s.levels {
+			if m.sessionMatchesTarget(session, target) {
+				volume = level
+			}
+		}
+
+		for target, maxVolume := range s.caps {
+			if m.sessionMatchesTarget(session, target) && volume > maxVolume {
+				volume = maxVolume
+			}
+		}
+	}
+
+	return volume
+}
+
+// reapplyAllSliders sets every slider's targets according to its last known value
+func (m *sessionMap) reapplyAllSliders() {
+	m.sliderValuesLock.Lock()
+	sliderValues := make(map[int]float32, len(m.sliderValues))
+	for sliderIdx, value := range m.sliderValues {
+		sliderValues[sliderIdx] = value
+	}
+	m.sliderValuesLock.Unlock()
+
+	for sliderIdx, value := range sliderValues {
+		m.adjustSlider(sliderIdx, value, false)
+	}
+}
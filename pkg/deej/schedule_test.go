@@ -0,0 +1,270 @@
+package deej
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field   string
+		min     int
+		max     int
+		want    []int
+		wantErr bool
+	}{
+		{field: "*", min: 0, max: 6, want: []int{0, 1, 2, 3, 4, 5, 6}},
+		{field: "5", min: 0, max: 59, want: []int{5}},
+		{field: "1-3", min: 0, max: 6, want: []int{1, 2, 3}},
+		{field: "1,4,6", min: 0, max: 6, want: []int{1, 4, 6}},
+		{field: "*/15", min: 0, max: 59, want: []int{0, 15, 30, 45}},
+		{field: "10-20/5", min: 0, max: 59, want: []int{10, 15, 20}},
+		{field: "1-2,5-6", min: 0, max: 6, want: []int{1, 2, 5, 6}},
+		{field: "7", min: 0, max: 6, wantErr: true},
+		{field: "0", min: 1, max: 31, wantErr: true},
+		{field: "5-1", min: 0, max: 6, wantErr: true},
+		{field: "*/0", min: 0, max: 59, wantErr: true},
+		{field: "a", min: 0, max: 59, wantErr: true},
+		{field: "1-b", min: 0, max: 59, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.field, func(t *testing.T) {
+			set, err := parseCronField(test.field, test.min, test.max)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", set)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := make(map[int]bool)
+			for _, value := range test.want {
+				want[value] = true
+			}
+
+			if !reflect.DeepEqual(set, want) {
+				t.Fatalf("got %v, want %v", set, want)
+			}
+		})
+	}
+}
+
+func TestParseWeekdays(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    []time.Weekday
+		wantErr bool
+	}{
+		{
+			name: "defaults to every day",
+			want: []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+		},
+		{
+			name:  "single days",
+			value: []interface{}{"mon", "WED"},
+			want:  []time.Weekday{time.Monday, time.Wednesday},
+		},
+		{
+			name:  "range",
+			value: []interface{}{"mon-fri"},
+			want:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		},
+		{
+			name:  "range wrapping around the week",
+			value: []interface{}{"fri-mon"},
+			want:  []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday},
+		},
+		{
+			name:    "unknown day",
+			value:   []interface{}{"funday"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown range end",
+			value:   []interface{}{"mon-someday"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			days, err := parseWeekdays(test.value)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", days)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := make(map[time.Weekday]bool)
+			for _, day := range test.want {
+				want[day] = true
+			}
+
+			if !reflect.DeepEqual(days, want) {
+				t.Fatalf("got %v, want %v", days, want)
+			}
+		})
+	}
+}
+
+func TestScheduleActive(t *testing.T) {
+
+	// 2024-01-01 is a monday
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2024, time.January, day, hour, minute, 0, 0, time.Local)
+	}
+
+	parse := func(t *testing.T, config map[string]interface{}) *schedule {
+		schedules, err := schedulesFromConfig([]interface{}{config})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return schedules[0]
+	}
+
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		now    time.Time
+		want   bool
+	}{
+		{
+			name:   "within a same-day window",
+			config: map[string]interface{}{"days": []interface{}{"mon"}, "from": "09:00", "to": "17:00"},
+			now:    at(1, 12, 0),
+			want:   true,
+		},
+		{
+			name:   "end of a same-day window is exclusive",
+			config: map[string]interface{}{"days": []interface{}{"mon"}, "from": "09:00", "to": "17:00"},
+			now:    at(1, 17, 0),
+			want:   false,
+		},
+		{
+			name:   "same-day window on another day",
+			config: map[string]interface{}{"days": []interface{}{"mon"}, "from": "09:00", "to": "17:00"},
+			now:    at(2, 12, 0),
+			want:   false,
+		},
+		{
+			name:   "midnight-spanning window before midnight",
+			config: map[string]interface{}{"days": []interface{}{"mon"}, "from": "22:00", "to": "07:00"},
+			now:    at(1, 23, 30),
+			want:   true,
+		},
+		{
+			name:   "midnight-spanning window after midnight belongs to the previous day",
+			config: map[string]interface{}{"days": []interface{}{"mon"}, "from": "22:00", "to": "07:00"},
+			now:    at(2, 6, 59),
+			want:   true,
+		},
+		{
+			name:   "midnight-spanning window ends on the next day",
+			config: map[string]interface{}{"days": []interface{}{"mon"}, "from": "22:00", "to": "07:00"},
+			now:    at(2, 7, 0),
+			want:   false,
+		},
+		{
+			name:   "midnight-spanning window doesn't start early on its own day",
+			config: map[string]interface{}{"days": []interface{}{"mon"}, "from": "22:00", "to": "07:00"},
+			now:    at(1, 6, 0),
+			want:   false,
+		},
+		{
+			name:   "same start and end time covers the whole day",
+			config: map[string]interface{}{"days": []interface{}{"mon"}, "from": "00:00", "to": "00:00"},
+			now:    at(1, 0, 0),
+			want:   true,
+		},
+		{
+			name:   "same start and end time covers the whole day until midnight",
+			config: map[string]interface{}{"days": []interface{}{"mon"}, "from": "08:00", "to": "08:00"},
+			now:    at(1, 23, 59),
+			want:   true,
+		},
+		{
+			name:   "same start and end time on another day",
+			config: map[string]interface{}{"days": []interface{}{"mon"}, "from": "08:00", "to": "08:00"},
+			now:    at(2, 12, 0),
+			want:   false,
+		},
+		{
+			name:   "cron window after its start",
+			config: map[string]interface{}{"cron": "30 9 * * 1-5", "duration": "1h"},
+			now:    at(1, 10, 29),
+			want:   true,
+		},
+		{
+			name:   "cron window after its duration",
+			config: map[string]interface{}{"cron": "30 9 * * 1-5", "duration": "1h"},
+			now:    at(1, 10, 31),
+			want:   false,
+		},
+		{
+			name:   "cron window spanning midnight",
+			config: map[string]interface{}{"cron": "0 23 * * 1", "duration": "2h"},
+			now:    at(2, 0, 30),
+			want:   true,
+		},
+		{
+			name:   "cron day of month or day of week, matching day of month",
+			config: map[string]interface{}{"cron": "0 12 15 * 1", "duration": "1h"},
+			now:    at(15, 12, 30), // a monday
+			want:   true,
+		},
+		{
+			name:   "cron day of month or day of week, matching day of week only",
+			config: map[string]interface{}{"cron": "0 12 15 * 2", "duration": "1h"},
+			now:    at(2, 12, 30), // a tuesday
+			want:   true,
+		},
+		{
+			name:   "cron day of month or day of week, matching neither",
+			config: map[string]interface{}{"cron": "0 12 15 * 2", "duration": "1h"},
+			now:    at(3, 12, 30),
+			want:   false,
+		},
+		{
+			name:   "cron unrestricted day of week only uses day of month",
+			config: map[string]interface{}{"cron": "0 12 15 * *", "duration": "1h"},
+			now:    at(2, 12, 30),
+			want:   false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := parse(t, test.config).active(test.now); got != test.want {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSchedulesFromConfigRequiresCronDuration(t *testing.T) {
+	for _, duration := range []interface{}{nil, "0s", "-1h"} {
+		config := map[string]interface{}{"cron": "0 9 * * *"}
+		if duration != nil {
+			config["duration"] = duration
+		}
+
+		if _, err := schedulesFromConfig([]interface{}{config}); err == nil {
+			t.Fatalf("expected an error for duration %v", duration)
+		}
+	}
+}
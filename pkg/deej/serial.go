@@ -8,6 +8,7 @@ import (
 	"math"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jacobsa/go-serial/serial"
@@ -30,7 +31,9 @@ type SerialIO struct {
 	conn        io.ReadWriteCloser
 
 	lastKnownNumSliders int
-	currentVolumeDatas  []VolumeData
+	currentVolumeDatas  map[int]VolumeData
+
+	reconnectLock sync.Locker
 
 	sliderMoveConsumers []chan SliderEvent
 }
@@ -50,6 +53,9 @@ type SliderEvent struct {
 	SliderID     int
 	PercentValue float32
 	ToggleMute   bool
+
+	// the first event for this slider since connecting or reloading the config
+	Initial bool
 }
 
 var expectedLinePattern = regexp.MustCompile(`^-?\d{1,4}(\|-?\d{1,4})*\r\n$`)
@@ -65,6 +71,8 @@ func NewSerialIO(deej *Deej, logger *zap.SugaredLogger) (*SerialIO, error) {
 		stopChannel:         make(chan bool),
 		connected:           false,
 		conn:                nil,
+		currentVolumeDatas:  make(map[int]VolumeData),
+		reconnectLock:       &sync.Mutex{},
 		sliderMoveConsumers: []chan SliderEvent{},
 	}
 
@@ -119,16 +127,34 @@ func (sio *SerialIO) Start() error {
 	namedLogger.Infow("Connected", "conn", sio.conn)
 	sio.connected = true
 
+	// the first frame of every connection should be treated as the sliders' initial values
+	sio.lastKnownNumSliders = 0
+
 	// read lines or await a stop
 	go func() {
 		connReader := bufio.NewReader(sio.conn)
-		bytesChannel := sio.readBytes(namedLogger, connReader)
+
+		// tells the reader to give up on a frame it's trying to deliver once we're done with it
+		done := make(chan bool)
+		defer close(done)
+
+		bytesChannel := sio.readBytes(namedLogger, connReader, done)
 
 		for {
 			select {
 			case <-sio.stopChannel:
 				sio.close(namedLogger)
-			case bytes := <-bytesChannel:
+
+				// a later connection gets its own reader, so this one is done
+				return
+			case bytes, ok := <-bytesChannel:
+
+				// the reader only stops when the connection fails, and there's nothing left to do but wait for a stop
+				if !ok {
+					bytesChannel = nil
+					continue
+				}
+
 				sio.handleBytes(namedLogger, bytes)
 			}
 		}
@@ -147,6 +173,30 @@ func (sio *SerialIO) Stop() {
 	}
 }
 
+// reconnect closes the serial connection (if it's still considered active) and opens it again,
+// retrying for a while since the device might not be back yet
+func (sio *SerialIO) reconnect(attempts int, interval time.Duration) {
+	sio.reconnectLock.Lock()
+	defer sio.reconnectLock.Unlock()
+
+	sio.logger.Info("Renewing serial connection")
+	sio.Stop()
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		<-time.After(interval)
+
+		err := sio.Start()
+		if err == nil {
+			sio.logger.Debugw("Renewed connection successfully", "attempt", attempt)
+			return
+		}
+
+		sio.logger.Debugw("Failed to renew serial connection, retrying", "attempt", attempt, "error", err)
+	}
+
+	sio.logger.Warnw("Giving up on renewing serial connection", "attempts", attempts)
+}
+
 // SubscribeToSliderMoveEvents returns an unbuffered channel that receives
 // a sliderMoveEvent struct every time a slider moves
 func (sio *SerialIO) SubscribeToSliderMoveEvents() chan SliderEvent {
@@ -208,23 +258,29 @@ func (sio *SerialIO) close(logger *zap.SugaredLogger) {
 	sio.connected = false
 }
 
-func (sio *SerialIO) readBytes(logger *zap.SugaredLogger, reader *bufio.Reader) chan []byte {
+// readBytes reads frames off the connection until it fails (including when it's closed), closing the returned channel
+func (sio *SerialIO) readBytes(logger *zap.SugaredLogger, reader *bufio.Reader, done chan bool) chan []byte {
 	ch := make(chan []byte)
 
 	go func() {
+		defer close(ch)
+
 		for {
-			b, _ := reader.ReadByte()
+			b, err := reader.ReadByte()
+			if err != nil {
+				logger.Debugw("Stopped reading from serial", "error", err)
+				return
+			}
 
 			if b != 0xAA {
 				continue
 			}
 
-			frameSize := len(sio.deej.config.SliderMapping.m)*2 + 1
+			frameSize := sio.deej.config.physicalSliderCount()*2 + 1
 			payload := make([]byte, frameSize)
 
 			if _, err := io.ReadFull(reader, payload); err != nil {
 				logger.Warnw("Failed to read bytes from serial", "error", err)
-				close(ch)
 				return
 			}
 
@@ -237,7 +293,11 @@ func (sio *SerialIO) readBytes(logger *zap.SugaredLogger, reader *bufio.Reader)
 				continue
 			}
 
-			ch <- payload[:frameSize-1]
+			select {
+			case ch <- payload[:frameSize-1]:
+			case <-done:
+				return
+			}
 		}
 	}()
 
@@ -247,7 +307,7 @@ func (sio *SerialIO) readBytes(logger *zap.SugaredLogger, reader *bufio.Reader)
 func (sio *SerialIO) handleBytes(logger *zap.SugaredLogger, bytes []byte) {
 	data := []ArduinoData{}
 
-	if len(bytes) != len(sio.deej.config.SliderMapping.m)*2 {
+	if len(bytes) != sio.deej.config.physicalSliderCount()*2 {
 		logger.Warnw("Wrong number of bytes received", "bytes number", len(bytes))
 		return
 	}
@@ -277,12 +337,9 @@ func (sio *SerialIO) handleBytes(logger *zap.SugaredLogger, bytes []byte) {
 	if numSliders != sio.lastKnownNumSliders {
 		logger.Infow("Detected sliders", "amount", numSliders)
 		sio.lastKnownNumSliders = numSliders
-		sio.currentVolumeDatas = make([]VolumeData, numSliders)
 
-		// reset everything to be an impossible value to force the slider move event later
-		for idx := range sio.currentVolumeDatas {
-			sio.currentVolumeDatas[idx].Value = -1.0
-		}
+		// forget every known value to force the slider move event later
+		sio.currentVolumeDatas = make(map[int]VolumeData)
 	}
 
 	// for each slider:
@@ -311,59 +368,94 @@ func (sio *SerialIO) handleBytes(logger *zap.SugaredLogger, bytes []byte) {
 
 		additive := slices.Contains(sio.deej.config.AdditiveIndices, sliderIdx)
 
-		if additive {
-			finalVolume := sio.deej.sessions.getCurrentVolume(sliderIdx)
+		// the physical slider comes first, followed by any logical channels derived from it
+		if event, ok := sio.channelEvent(logger, sliderIdx, normalizedScalar, number, additive, arduinoData.ToggleMute); ok {
+			sliderEvents = append(sliderEvents, event)
+		}
 
-			if finalVolume < 0 {
+		for channelIdx, channel := range sio.deej.config.LogicalChannels {
+			if channel.source != sliderIdx {
 				continue
 			}
 
-			if number != 0 {
-				finalVolume += normalizedScalar
+			channelScalar := channel.transform(normalizedScalar, additive)
 
-				if finalVolume < 0 {
-					finalVolume = 0
-				}
-				if finalVolume > 1 {
-					finalVolume = 1
-				}
+			if event, ok := sio.channelEvent(logger, channelIdx, channelScalar, number, additive, arduinoData.ToggleMute); ok {
+				sliderEvents = append(sliderEvents, event)
 			}
+		}
+	}
 
-			normalizedScalar = finalVolume
+	// deliver move events if there are any, towards all potential consumers
+	if len(sliderEvents) > 0 {
+		for _, consumer := range sio.sliderMoveConsumers {
+			for _, moveEvent := range sliderEvents {
+				consumer <- moveEvent
+			}
 		}
+	}
+}
 
-		if sio.deej.config.UseLogVolume && !additive {
-			normalizedScalar = LinearToLog(normalizedScalar)
+// channelEvent turns a normalized value for a (physical or logical) slider into a move event,
+// if it differs from the last value seen for that slider or its mute button was pressed
+func (sio *SerialIO) channelEvent(
+	logger *zap.SugaredLogger,
+	sliderIdx int,
+	normalizedScalar float32,
+	number int,
+	additive bool,
+	toggleMute bool,
+) (SliderEvent, bool) {
+	if additive {
+		finalVolume := sio.deej.sessions.getCurrentVolume(sliderIdx)
+
+		if finalVolume < 0 {
+			return SliderEvent{}, false
 		}
 
-		significantlyDifferent := math.Abs(float64(sio.currentVolumeDatas[sliderIdx].Value-normalizedScalar)) != 0
+		if number != 0 {
+			finalVolume += normalizedScalar
 
-		if significantlyDifferent || arduinoData.ToggleMute {
+			if finalVolume < 0 {
+				finalVolume = 0
+			}
+			if finalVolume > 1 {
+				finalVolume = 1
+			}
+		}
 
-			// if it does, update the saved value and create a move event
-			sio.currentVolumeDatas[sliderIdx].Value = normalizedScalar
-			sio.currentVolumeDatas[sliderIdx].Mute = !sio.currentVolumeDatas[sliderIdx].Mute
+		normalizedScalar = finalVolume
+	}
 
-			sliderEvents = append(sliderEvents, SliderEvent{
-				SliderID:     sliderIdx,
-				PercentValue: normalizedScalar,
-				ToggleMute:   arduinoData.ToggleMute,
-			})
+	if sio.deej.config.UseLogVolume && !additive {
+		normalizedScalar = LinearToLog(normalizedScalar)
+	}
 
-			if sio.deej.Verbose() {
-				logger.Debugw("Slider event", "event", sliderEvents[len(sliderEvents)-1])
-			}
-		}
+	current, known := sio.currentVolumeDatas[sliderIdx]
+	significantlyDifferent := !known || math.Abs(float64(current.Value-normalizedScalar)) != 0
+
+	if !significantlyDifferent && !toggleMute {
+		return SliderEvent{}, false
 	}
 
-	// deliver move events if there are any, towards all potential consumers
-	if len(sliderEvents) > 0 {
-		for _, consumer := range sio.sliderMoveConsumers {
-			for _, moveEvent := range sliderEvents {
-				consumer <- moveEvent
-			}
-		}
+	// if it does, update the saved value and create a move event
+	sio.currentVolumeDatas[sliderIdx] = VolumeData{
+		Value: normalizedScalar,
+		Mute:  !current.Mute,
+	}
+
+	event := SliderEvent{
+		SliderID:     sliderIdx,
+		PercentValue: normalizedScalar,
+		ToggleMute:   toggleMute,
+		Initial:      !known,
 	}
+
+	if sio.deej.Verbose() {
+		logger.Debugw("Slider event", "event", event)
+	}
+
+	return event, true
 }
 
 const (
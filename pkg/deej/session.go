@@ -24,10 +24,41 @@ const (
 	// but it will not call the child GetVolume correctly :/
 	sessionCreationLogMessage = "Created audio session instance"
 
-	// format this with s.humanReadableDesc and whatever the current volume is
-	sessionStringFormat = "<session: %s, vol: %.2f>"
+	// format this with s.humanReadableDesc, whatever the current volume is and whether it's muted
+	sessionStringFormat = "<session: %s, vol: %.2f, muted: %t>"
 )
 
+// deviceSession is implemented by sessions that can control a specific audio device (rather than a single app),
+// and can be targeted by more than just their key
+type deviceSession interface {
+	// deviceNames returns the lowercase names this device can be targeted by, or none if it isn't a device session
+	deviceNames() []string
+}
+
+// aliasedSession is implemented by sessions whose app is known by more than one name
+// (e.g. a wine game's .exe and the loader running it), any of which can be used to target it
+type aliasedSession interface {
+	// aliases returns the lowercase names this session can be targeted by, besides its key
+	aliases() []string
+}
+
+// corkableSession is implemented by sessions whose stream can be paused (corked) by its app,
+// which is how most apps stop playing without closing their stream
+type corkableSession interface {
+	corked() bool
+}
+
+// peakSession is implemented by sessions that can report their current peak level (between 0 and 1)
+type peakSession interface {
+	peak() (float32, bool)
+}
+
+// propertySession is implemented by sessions that carry properties describing their stream
+// (e.g. application.name or media.role on PulseAudio), which "prop:" targets are matched against
+type propertySession interface {
+	properties() map[string]string
+}
+
 type baseSession struct {
 	logger *zap.SugaredLogger
 	system bool
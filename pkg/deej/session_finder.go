@@ -6,3 +6,21 @@ type SessionFinder interface {
 
 	Release() error
 }
+
+// SessionNotifier represents a SessionFinder that can also report sessions as they appear and disappear,
+// so that the session map can keep itself up to date without polling
+type SessionNotifier interface {
+	SubscribeToSessionChanges() chan SessionChange
+}
+
+// SessionChange describes a single session that appeared or disappeared
+type SessionChange struct {
+	Session Session
+	Removed bool
+
+	// set when an existing session changed in a way that affects which targets it matches (e.g. its properties)
+	Changed bool
+
+	// set when the finder lost track of changes, and all sessions should be re-acquired instead
+	Refresh bool
+}
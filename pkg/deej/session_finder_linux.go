@@ -3,6 +3,10 @@ package deej
 import (
 	"fmt"
 	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jfreymuth/pulse/proto"
 	"go.uber.org/zap"
@@ -14,8 +18,61 @@ type paSessionFinder struct {
 
 	client *proto.Client
 	conn   net.Conn
+
+	// the sessions handed out by the last GetAllSessions call and any changes since, so that
+	// removals can be reported with the same session instance that was added
+	sinkInputs   map[uint32]Session
+	sinks        map[uint32]Session
+	sources      map[uint32]Session
+	masterSink   *masterSession
+	masterSource *masterSession
+	trackingLock sync.Locker
+
+	// sink inputs that couldn't be named, so that they're only warned about once. they're still looked at
+	// whenever they change, since some streams only get their properties after being created
+	unnamedSinkInputs map[uint32]bool
+
+	// raw events from the server, which are handled outside of the client's read loop
+	pulseEvents chan *proto.SubscribeEvent
+
+	// known sink inputs whose changes are waiting to be looked at. most changes are just volume or mute,
+	// which come in bursts and don't concern us, so each stream is only queried once per paChangeQueryDelay
+	pendingChanges        map[uint32]bool
+	pendingChangesChannel chan uint32
+
+	// set (atomically) whenever an event had to be dropped
+	pulseEventsDropped int32
+
+	changeConsumers []chan SessionChange
 }
 
+// PulseAudio's subscription masks and event bits, which aren't exposed by the proto package
+const (
+	paSubscriptionMaskSink      = 0x0001
+	paSubscriptionMaskSource    = 0x0002
+	paSubscriptionMaskSinkInput = 0x0004
+	paSubscriptionMaskServer    = 0x0080
+
+	paEventFacilityMask      = 0x000F
+	paEventFacilitySink      = 0x0000
+	paEventFacilitySource    = 0x0001
+	paEventFacilitySinkInput = 0x0002
+	paEventFacilityServer    = 0x0007
+
+	paEventTypeMask   = 0x0030
+	paEventTypeNew    = 0x0000
+	paEventTypeChange = 0x0010
+	paEventTypeRemove = 0x0020
+
+	paMediaRoleEvent = "event"
+
+	// events arrive in bursts (e.g. while dragging a volume in pavucontrol), so leave them plenty of room
+	paEventBufferSize = 256
+
+	// how long changes to a known sink input are collected before its info is requested again
+	paChangeQueryDelay = 200 * time.Millisecond
+)
+
 func newSessionFinder(logger *zap.SugaredLogger) (SessionFinder, error) {
 	client, conn, err := proto.Connect("")
 	if err != nil {
@@ -35,12 +92,46 @@ func newSessionFinder(logger *zap.SugaredLogger) (SessionFinder, error) {
 	}
 
 	sf := &paSessionFinder{
-		logger:        logger.Named("session_finder"),
-		sessionLogger: logger.Named("sessions"),
-		client:        client,
-		conn:          conn,
+		logger:                logger.Named("session_finder"),
+		sessionLogger:         logger.Named("sessions"),
+		client:                client,
+		conn:                  conn,
+		sinkInputs:            make(map[uint32]Session),
+		sinks:                 make(map[uint32]Session),
+		sources:               make(map[uint32]Session),
+		trackingLock:          &sync.Mutex{},
+		unnamedSinkInputs:     make(map[uint32]bool),
+		pulseEvents:           make(chan *proto.SubscribeEvent, paEventBufferSize),
+		pendingChanges:        make(map[uint32]bool),
+		pendingChangesChannel: make(chan uint32, paEventBufferSize),
+		changeConsumers:       []chan SessionChange{},
+	}
+
+	// the callback runs in the client's read loop, so it can't make requests of its own
+	client.Callback = func(message interface{}) {
+		event, ok := message.(*proto.SubscribeEvent)
+		if !ok {
+			return
+		}
+
+		select {
+		case sf.pulseEvents <- event:
+		default:
+			atomic.StoreInt32(&sf.pulseEventsDropped, 1)
+		}
+	}
+
+	subscribeRequest := proto.Subscribe{
+		Mask: paSubscriptionMaskSink | paSubscriptionMaskSource | paSubscriptionMaskSinkInput | paSubscriptionMaskServer,
 	}
 
+	if err := client.Request(&subscribeRequest, nil); err != nil {
+		logger.Warnw("Failed to subscribe to PulseAudio events", "error", err)
+		return nil, fmt.Errorf("subscribe to PulseAudio events: %w", err)
+	}
+
+	go sf.handlePulseEvents()
+
 	sf.logger.Debug("Created PA session finder instance")
 
 	return sf, nil
@@ -49,10 +140,20 @@ func newSessionFinder(logger *zap.SugaredLogger) (SessionFinder, error) {
 func (sf *paSessionFinder) GetAllSessions() ([]Session, error) {
 	sessions := []Session{}
 
+	sf.trackingLock.Lock()
+	defer sf.trackingLock.Unlock()
+
+	sf.sinkInputs = make(map[uint32]Session)
+	sf.sinks = make(map[uint32]Session)
+	sf.sources = make(map[uint32]Session)
+	sf.masterSink = nil
+	sf.masterSource = nil
+
 	// get the master sink session
 	masterSink, err := sf.getMasterSinkSession()
 	if err == nil {
 		sessions = append(sessions, masterSink)
+		sf.masterSink = masterSink
 	} else {
 		sf.logger.Warnw("Failed to get master audio sink session", "error", err)
 	}
@@ -61,10 +162,16 @@ func (sf *paSessionFinder) GetAllSessions() ([]Session, error) {
 	masterSource, err := sf.getMasterSourceSession()
 	if err == nil {
 		sessions = append(sessions, masterSource)
+		sf.masterSource = masterSource
 	} else {
 		sf.logger.Warnw("Failed to get master audio source session", "error", err)
 	}
 
+	// every sink and source can also be targeted by name, regardless of which one is the default
+	if err := sf.enumerateAndAddDeviceSessions(&sessions); err != nil {
+		sf.logger.Warnw("Failed to enumerate audio devices", "error", err)
+	}
+
 	// enumerate sink inputs and add sessions along the way
 	if err := sf.enumerateAndAddSessions(&sessions); err != nil {
 		sf.logger.Warnw("Failed to enumerate audio sessions", "error", err)
@@ -85,7 +192,7 @@ func (sf *paSessionFinder) Release() error {
 	return nil
 }
 
-func (sf *paSessionFinder) getMasterSinkSession() (Session, error) {
+func (sf *paSessionFinder) getMasterSinkSession() (*masterSession, error) {
 	request := proto.GetSinkInfo{
 		SinkIndex: proto.Undefined,
 	}
@@ -102,7 +209,7 @@ func (sf *paSessionFinder) getMasterSinkSession() (Session, error) {
 	return sink, nil
 }
 
-func (sf *paSessionFinder) getMasterSourceSession() (Session, error) {
+func (sf *paSessionFinder) getMasterSourceSession() (*masterSession, error) {
 	request := proto.GetSourceInfo{
 		SourceIndex: proto.Undefined,
 	}
@@ -128,23 +235,481 @@ func (sf *paSessionFinder) enumerateAndAddSessions(sessions *[]Session) error {
 		return fmt.Errorf("get sink input list: %w", err)
 	}
 
-	for _, info := range reply {
-		name, ok := info.Properties["application.process.binary"]
+	unnamedSinkInputs := make(map[uint32]bool)
 
+	for _, info := range reply {
+		newSession, ok := sf.sessionFromSinkInputInfo(info)
 		if !ok {
-			sf.logger.Warnw("Failed to get sink input's process name",
-				"sinkInputIndex", info.SinkInputIndex)
+			if !sf.unnamedSinkInputs[info.SinkInputIndex] {
+				sf.logger.Warnw("Failed to get sink input's process name", "sinkInputIndex", info.SinkInputIndex)
+			}
+
+			unnamedSinkInputs[info.SinkInputIndex] = true
 
 			continue
 		}
 
-		// create the deej session object
-		newSession := newPASession(sf.sessionLogger, sf.client, info.SinkInputIndex, info.Channels, name.String())
-
 		// add it to our slice
 		*sessions = append(*sessions, newSession)
+		sf.sinkInputs[info.SinkInputIndex] = newSession
+	}
+
+	// streams that went away (or got their names) since the last time don't need remembering
+	sf.unnamedSinkInputs = unnamedSinkInputs
+
+	return nil
+}
 
+func (sf *paSessionFinder) enumerateAndAddDeviceSessions(sessions *[]Session) error {
+	sinkRequest := proto.GetSinkInfoList{}
+	sinkReply := proto.GetSinkInfoListReply{}
+
+	if err := sf.client.Request(&sinkRequest, &sinkReply); err != nil {
+		sf.logger.Warnw("Failed to get sink list", "error", err)
+		return fmt.Errorf("get sink list: %w", err)
+	}
+
+	for _, info := range sinkReply {
+		device := sf.sessionFromSinkInfo(info)
+
+		*sessions = append(*sessions, device)
+		sf.sinks[info.SinkIndex] = device
+	}
+
+	sourceRequest := proto.GetSourceInfoList{}
+	sourceReply := proto.GetSourceInfoListReply{}
+
+	if err := sf.client.Request(&sourceRequest, &sourceReply); err != nil {
+		sf.logger.Warnw("Failed to get source list", "error", err)
+		return fmt.Errorf("get source list: %w", err)
+	}
+
+	for _, info := range sourceReply {
+		device, ok := sf.sessionFromSourceInfo(info)
+		if !ok {
+			continue
+		}
+
+		*sessions = append(*sessions, device)
+		sf.sources[info.SourceIndex] = device
 	}
 
 	return nil
 }
+
+func (sf *paSessionFinder) sessionFromSinkInfo(info *proto.GetSinkInfoReply) Session {
+	return newDeviceSession(sf.sessionLogger, sf.client, info.SinkIndex, info.Channels, true, info.SinkName, info.Device)
+}
+
+func (sf *paSessionFinder) sessionFromSourceInfo(info *proto.GetSourceInfoReply) (Session, bool) {
+
+	// every sink has a monitor source, which isn't something anyone would want a slider for
+	if info.MonitorSourceIndex != proto.Undefined {
+		return nil, false
+	}
+
+	return newDeviceSession(sf.sessionLogger, sf.client, info.SourceIndex, info.Channels, false, info.SourceName, info.Device), true
+}
+
+// handleDeviceEvent adds or removes the device session for a sink or source that appeared or disappeared
+func (sf *paSessionFinder) handleDeviceEvent(index uint32, isSink bool, eventType uint32) {
+	sf.trackingLock.Lock()
+	existing, known := sf.deviceSessions(isSink)[index]
+	sf.trackingLock.Unlock()
+
+	if eventType == paEventTypeRemove {
+		if !known {
+			return
+		}
+
+		sf.trackingLock.Lock()
+		delete(sf.deviceSessions(isSink), index)
+		sf.trackingLock.Unlock()
+
+		sf.logger.Infow("Audio device removed", "session", existing)
+		sf.notifyChange(SessionChange{Session: existing, Removed: true})
+
+		return
+	}
+
+	if known {
+		return
+	}
+
+	var device Session
+
+	if isSink {
+		request := proto.GetSinkInfo{
+			SinkIndex: index,
+		}
+		reply := proto.GetSinkInfoReply{}
+
+		if err := sf.client.Request(&request, &reply); err != nil {
+			sf.logger.Debugw("Failed to get new sink info", "sinkIndex", index, "error", err)
+			return
+		}
+
+		device = sf.sessionFromSinkInfo(&reply)
+	} else {
+		request := proto.GetSourceInfo{
+			SourceIndex: index,
+		}
+		reply := proto.GetSourceInfoReply{}
+
+		if err := sf.client.Request(&request, &reply); err != nil {
+			sf.logger.Debugw("Failed to get new source info", "sourceIndex", index, "error", err)
+			return
+		}
+
+		var ok bool
+		if device, ok = sf.sessionFromSourceInfo(&reply); !ok {
+			return
+		}
+	}
+
+	sf.trackingLock.Lock()
+	sf.deviceSessions(isSink)[index] = device
+	sf.trackingLock.Unlock()
+
+	sf.logger.Infow("Audio device added", "session", device)
+	sf.notifyChange(SessionChange{Session: device})
+}
+
+// deviceSessions returns the tracked sink or source sessions. the tracking lock must be held by the caller
+func (sf *paSessionFinder) deviceSessions(isSink bool) map[uint32]Session {
+	if isSink {
+		return sf.sinks
+	}
+
+	return sf.sources
+}
+
+func (sf *paSessionFinder) sessionFromSinkInputInfo(info *proto.GetSinkInputInfoReply) (Session, bool) {
+	name, ok := info.Properties["application.process.binary"]
+
+	// some streams (i.e. from flatpaks or sandboxed browsers) don't say which binary they belong to,
+	// but can still be told apart by their application name or any other property
+	if !ok {
+		name, ok = info.Properties["application.name"]
+	}
+
+	eventSound := isEventSound(info)
+
+	// event sounds (i.e. played straight from the sample cache) often don't belong to any app at all,
+	// but they're all keyed as "system" anyway, so they only need a name to tell them apart in the logs
+	if !ok && eventSound {
+		name, ok = info.Properties["event.id"]
+		if !ok {
+			name, ok = proto.PropListString(paMediaRoleEvent), true
+		}
+	}
+
+	if !ok {
+		return nil, false
+	}
+
+	props := streamProperties(info.Properties)
+
+	// the binary may not be what the app is known by (i.e. under wine), so look at its process too
+	identity := resolveProcessIdentity(props, name.String())
+
+	// create the deej session object
+	session := newPASession(sf.sessionLogger,
+		sf.client,
+		info.SinkInputIndex,
+		info.Channels,
+		identity.name,
+		identity.aliases,
+		eventSound,
+		props)
+
+	session.setCorked(info.Corked)
+
+	return session, true
+}
+
+// streamProperties converts a stream's property list to plain strings, skipping binary values
+func streamProperties(propList proto.PropList) map[string]string {
+	props := make(map[string]string, len(propList))
+
+	for key, value := range propList {
+		if strings.HasPrefix(key, "application.icon") {
+			continue
+		}
+
+		props[key] = value.String()
+	}
+
+	return props
+}
+
+// isEventSound returns true for sink inputs playing notification and event sounds (e.g. through libcanberra
+// or the sample cache), which make up the "system" session. their volume is saved per role by module-stream-restore,
+// so setting it for one of them also affects the ones that come after it
+func isEventSound(info *proto.GetSinkInputInfoReply) bool {
+	if role, ok := info.Properties["media.role"]; ok && role.String() == paMediaRoleEvent {
+		return true
+	}
+
+	_, hasEventID := info.Properties["event.id"]
+	return hasEventID
+}
+
+// SubscribeToSessionChanges returns an unbuffered channel that receives every session that appears or disappears
+func (sf *paSessionFinder) SubscribeToSessionChanges() chan SessionChange {
+	c := make(chan SessionChange)
+	sf.changeConsumers = append(sf.changeConsumers, c)
+
+	return c
+}
+
+// handlePulseEvents turns the server's subscription events into session changes, until the connection is closed
+func (sf *paSessionFinder) handlePulseEvents() {
+	for {
+		var event *proto.SubscribeEvent
+
+		select {
+		case event = <-sf.pulseEvents:
+		case index := <-sf.pendingChangesChannel:
+			sf.handlePendingChange(index)
+			continue
+		}
+
+		// if we missed anything, incremental changes can't be trusted anymore
+		if atomic.CompareAndSwapInt32(&sf.pulseEventsDropped, 1, 0) {
+			sf.logger.Warn("Dropped PulseAudio events, asking for a full session refresh")
+			sf.notifyChange(SessionChange{Refresh: true})
+		}
+
+		facility := event.Event & paEventFacilityMask
+		eventType := event.Event & paEventTypeMask
+
+		switch facility {
+		case paEventFacilitySinkInput:
+			sf.handleSinkInputEvent(event.Index, eventType)
+
+		// a changed default device is reported as a server change (e.g. switching to a usb headset)
+		case paEventFacilityServer:
+			sf.checkMasterSessions()
+
+		// added and removed devices are tracked as device sessions, and a removed default one should be replaced.
+		// changes (i.e. volume) are ignored, since sessions read them live
+		case paEventFacilitySink, paEventFacilitySource:
+			if eventType == paEventTypeChange {
+				continue
+			}
+
+			sf.handleDeviceEvent(event.Index, facility == paEventFacilitySink, eventType)
+			sf.checkMasterSessions()
+		}
+	}
+}
+
+func (sf *paSessionFinder) handleSinkInputEvent(index uint32, eventType uint32) {
+	sf.trackingLock.Lock()
+	existing, known := sf.sinkInputs[index]
+	sf.trackingLock.Unlock()
+
+	switch eventType {
+	case paEventTypeRemove:
+		sf.trackingLock.Lock()
+		delete(sf.unnamedSinkInputs, index)
+		sf.trackingLock.Unlock()
+
+		if !known {
+			return
+		}
+
+		sf.trackingLock.Lock()
+		delete(sf.sinkInputs, index)
+		sf.trackingLock.Unlock()
+
+		sf.logger.Debugw("Sink input removed", "sinkInputIndex", index, "session", existing)
+		sf.notifyChange(SessionChange{Session: existing, Removed: true})
+
+	// streams sometimes only get their properties after being created, so changes to unknown ones count as new
+	case paEventTypeNew, paEventTypeChange:
+		if known && eventType == paEventTypeChange {
+			sf.deferChange(index)
+			return
+		}
+
+		request := proto.GetSinkInputInfo{
+			SinkInputIndex: index,
+		}
+		reply := proto.GetSinkInputInfoReply{}
+
+		if err := sf.client.Request(&request, &reply); err != nil {
+			sf.logger.Debugw("Failed to get sink input info", "sinkInputIndex", index, "error", err)
+			return
+		}
+
+		// known streams can still change their properties, i.e. media.name when a new song or video starts,
+		// and get corked or uncorked as their app pauses and resumes playback
+		if known {
+			sf.updateSinkInput(existing, &reply)
+			return
+		}
+
+		newSession, ok := sf.sessionFromSinkInputInfo(&reply)
+
+		sf.trackingLock.Lock()
+		warned := sf.unnamedSinkInputs[index]
+
+		if ok {
+			delete(sf.unnamedSinkInputs, index)
+			sf.sinkInputs[index] = newSession
+		} else {
+			sf.unnamedSinkInputs[index] = true
+		}
+		sf.trackingLock.Unlock()
+
+		if !ok {
+			if !warned {
+				sf.logger.Warnw("Failed to get sink input's process name", "sinkInputIndex", index)
+			}
+
+			return
+		}
+
+		sf.logger.Debugw("Sink input added", "sinkInputIndex", index, "session", newSession)
+		sf.notifyChange(SessionChange{Session: newSession})
+	}
+}
+
+// deferChange schedules a known sink input to be looked at again once its burst of changes is over
+func (sf *paSessionFinder) deferChange(index uint32) {
+	sf.trackingLock.Lock()
+	defer sf.trackingLock.Unlock()
+
+	if sf.pendingChanges[index] {
+		return
+	}
+
+	sf.pendingChanges[index] = true
+
+	time.AfterFunc(paChangeQueryDelay, func() {
+		sf.pendingChangesChannel <- index
+	})
+}
+
+// handlePendingChange looks at a known sink input whose changes were deferred, if it's still around
+func (sf *paSessionFinder) handlePendingChange(index uint32) {
+	sf.trackingLock.Lock()
+	delete(sf.pendingChanges, index)
+	existing, known := sf.sinkInputs[index]
+	sf.trackingLock.Unlock()
+
+	if !known {
+		return
+	}
+
+	request := proto.GetSinkInputInfo{
+		SinkInputIndex: index,
+	}
+	reply := proto.GetSinkInputInfoReply{}
+
+	if err := sf.client.Request(&request, &reply); err != nil {
+		sf.logger.Debugw("Failed to get sink input info", "sinkInputIndex", index, "error", err)
+		return
+	}
+
+	sf.updateSinkInput(existing, &reply)
+}
+
+// updateSinkInput brings a known sink input's session up to date, reporting it as changed if its properties did
+func (sf *paSessionFinder) updateSinkInput(existing Session, info *proto.GetSinkInputInfoReply) {
+	session, ok := existing.(*paSession)
+	if !ok {
+		return
+	}
+
+	session.setCorked(info.Corked)
+
+	if session.setProperties(streamProperties(info.Properties)) {
+		sf.notifyChange(SessionChange{Session: existing, Changed: true})
+	}
+}
+
+// checkMasterSessions rebinds the master and mic sessions if the default sink or source changed
+func (sf *paSessionFinder) checkMasterSessions() {
+	changes := append(sf.checkDefaultSink(), sf.checkDefaultSource()...)
+
+	for _, change := range changes {
+		sf.notifyChange(change)
+	}
+}
+
+func (sf *paSessionFinder) checkDefaultSink() []SessionChange {
+	request := proto.GetSinkInfo{
+		SinkIndex: proto.Undefined,
+	}
+	reply := proto.GetSinkInfoReply{}
+
+	if err := sf.client.Request(&request, &reply); err != nil {
+		sf.logger.Debugw("Failed to get default sink info", "error", err)
+		return nil
+	}
+
+	sf.trackingLock.Lock()
+	defer sf.trackingLock.Unlock()
+
+	if sf.masterSink != nil && sf.masterSink.streamIndex == reply.SinkIndex {
+		return nil
+	}
+
+	changes := []SessionChange{}
+	if sf.masterSink != nil {
+		changes = append(changes, SessionChange{Session: sf.masterSink, Removed: true})
+	}
+
+	sf.masterSink = newMasterSession(sf.sessionLogger, sf.client, reply.SinkIndex, reply.Channels, true)
+	changes = append(changes, SessionChange{Session: sf.masterSink})
+
+	sf.logger.Infow("Default sink changed, master now controls it",
+		"device", reply.Device,
+		"sinkName", reply.SinkName,
+		"sinkIndex", reply.SinkIndex)
+
+	return changes
+}
+
+func (sf *paSessionFinder) checkDefaultSource() []SessionChange {
+	request := proto.GetSourceInfo{
+		SourceIndex: proto.Undefined,
+	}
+	reply := proto.GetSourceInfoReply{}
+
+	if err := sf.client.Request(&request, &reply); err != nil {
+		sf.logger.Debugw("Failed to get default source info", "error", err)
+		return nil
+	}
+
+	sf.trackingLock.Lock()
+	defer sf.trackingLock.Unlock()
+
+	if sf.masterSource != nil && sf.masterSource.streamIndex == reply.SourceIndex {
+		return nil
+	}
+
+	changes := []SessionChange{}
+	if sf.masterSource != nil {
+		changes = append(changes, SessionChange{Session: sf.masterSource, Removed: true})
+	}
+
+	sf.masterSource = newMasterSession(sf.sessionLogger, sf.client, reply.SourceIndex, reply.Channels, false)
+	changes = append(changes, SessionChange{Session: sf.masterSource})
+
+	sf.logger.Infow("Default source changed, mic now controls it",
+		"device", reply.Device,
+		"sourceName", reply.SourceName,
+		"sourceIndex", reply.SourceIndex)
+
+	return changes
+}
+
+func (sf *paSessionFinder) notifyChange(change SessionChange) {
+	for _, consumer := range sf.changeConsumers {
+		consumer <- change
+	}
+}
@@ -478,8 +478,20 @@ func (sf *wcaSessionFinder) enumerateAndAddProcessSessions(
 		// make it useful, again
 		simpleAudioVolume := (*wca.ISimpleAudioVolume)(unsafe.Pointer(dispatch))
 
+		// get its IAudioMeterInformation too, if it has one. it's only used to tell whether the session is playing,
+		// so it's fine to go without it
+		var audioMeter *iAudioMeterInformation
+
+		if dispatch, err = audioSessionControl2.QueryInterface(wca.IID_IAudioMeterInformation); err != nil {
+			sf.logger.Debugw("Failed to query session's IAudioMeterInformation",
+				"error", err,
+				"sessionIdx", sessionIdx)
+		} else {
+			audioMeter = (*iAudioMeterInformation)(unsafe.Pointer(dispatch))
+		}
+
 		// create the deej session object
-		newSession, err := newWCASession(sf.sessionLogger, audioSessionControl2, simpleAudioVolume, pid, sf.eventCtx)
+		newSession, err := newWCASession(sf.sessionLogger, audioSessionControl2, simpleAudioVolume, audioMeter, pid, sf.eventCtx)
 		if err != nil {
 
 			// this could just mean this process is already closed by now, and the session will be cleaned up later by the OS
@@ -497,6 +509,10 @@ func (sf *wcaSessionFinder) enumerateAndAddProcessSessions(
 			audioSessionControl2.Release()
 			simpleAudioVolume.Release()
 
+			if audioMeter != nil {
+				audioMeter.Release()
+			}
+
 			continue
 		}
 
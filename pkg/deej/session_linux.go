@@ -3,6 +3,9 @@ package deej
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 
 	"go.uber.org/zap"
 
@@ -19,10 +22,18 @@ type paSession struct {
 
 	processName string
 
+	// other names the session's app goes by, see processIdentity
+	aliasNames []string
+
 	client *proto.Client
 
 	sinkInputIndex    uint32
 	sinkInputChannels byte
+
+	// the stream's properties and whether it's corked, updated whenever the server reports they changed
+	props     map[string]string
+	isCorked  bool
+	propsLock sync.Locker
 }
 
 type masterSession struct {
@@ -33,6 +44,10 @@ type masterSession struct {
 	streamIndex    uint32
 	streamChannels byte
 	isOutput       bool
+
+	// set for sessions that control a specific sink or source, rather than the default one
+	device     bool
+	deviceName string
 }
 
 func newPASession(
@@ -41,18 +56,29 @@ func newPASession(
 	sinkInputIndex uint32,
 	sinkInputChannels byte,
 	processName string,
+	aliasNames []string,
+	system bool,
+	props map[string]string,
 ) *paSession {
 
 	s := &paSession{
 		client:            client,
 		sinkInputIndex:    sinkInputIndex,
 		sinkInputChannels: sinkInputChannels,
+		aliasNames:        aliasNames,
+		props:             props,
+		propsLock:         &sync.Mutex{},
 	}
 
 	s.processName = processName
+	s.system = system
 	s.name = processName
 	s.humanReadableDesc = processName
 
+	if system {
+		s.humanReadableDesc = fmt.Sprintf("%s (%s)", systemSessionName, processName)
+	}
+
 	// use a self-identifying session name e.g. deej.sessions.chrome
 	s.logger = logger.Named(s.Key())
 	s.logger.Debugw(sessionCreationLogMessage, "session", s)
@@ -93,6 +119,36 @@ func newMasterSession(
 	return s
 }
 
+// newDeviceSession creates a session for a specific sink or source, keyed by its description (e.g. "Built-in Audio Analog Stereo")
+func newDeviceSession(
+	logger *zap.SugaredLogger,
+	client *proto.Client,
+	streamIndex uint32,
+	streamChannels byte,
+	isOutput bool,
+	name string,
+	description string,
+) *masterSession {
+
+	s := &masterSession{
+		client:         client,
+		streamIndex:    streamIndex,
+		streamChannels: streamChannels,
+		isOutput:       isOutput,
+		device:         true,
+		deviceName:     name,
+	}
+
+	s.master = true
+	s.name = description
+	s.humanReadableDesc = description
+
+	s.logger = logger.Named(strings.ToLower(name))
+	s.logger.Debugw(sessionCreationLogMessage, "session", s)
+
+	return s
+}
+
 func (s *paSession) GetVolume() float32 {
 	request := proto.GetSinkInputInfo{
 		SinkInputIndex: s.sinkInputIndex,
@@ -125,12 +181,79 @@ func (s *paSession) SetVolume(v float32) error {
 	return nil
 }
 
+func (s *paSession) GetMute() bool {
+	request := proto.GetSinkInputInfo{
+		SinkInputIndex: s.sinkInputIndex,
+	}
+	reply := proto.GetSinkInputInfoReply{}
+
+	if err := s.client.Request(&request, &reply); err != nil {
+		s.logger.Warnw("Failed to get session mute", "error", err)
+	}
+
+	return reply.Muted
+}
+
+func (s *paSession) SetMute(m bool) error {
+	request := proto.SetSinkInputMute{
+		SinkInputIndex: s.sinkInputIndex,
+		Mute:           m,
+	}
+
+	if err := s.client.Request(&request, nil); err != nil {
+		s.logger.Warnw("Failed to set session mute", "error", err)
+		return fmt.Errorf("adjust session mute: %w", err)
+	}
+
+	s.logger.Debugw("Adjusting session mute", "to", m)
+
+	return nil
+}
+
+func (s *paSession) aliases() []string {
+	return s.aliasNames
+}
+
+func (s *paSession) properties() map[string]string {
+	s.propsLock.Lock()
+	defer s.propsLock.Unlock()
+
+	return s.props
+}
+
+// setProperties replaces the stream's properties, returning false if they didn't actually change
+func (s *paSession) setProperties(props map[string]string) bool {
+	s.propsLock.Lock()
+	defer s.propsLock.Unlock()
+
+	if reflect.DeepEqual(s.props, props) {
+		return false
+	}
+
+	s.props = props
+	return true
+}
+
+func (s *paSession) corked() bool {
+	s.propsLock.Lock()
+	defer s.propsLock.Unlock()
+
+	return s.isCorked
+}
+
+func (s *paSession) setCorked(corked bool) {
+	s.propsLock.Lock()
+	defer s.propsLock.Unlock()
+
+	s.isCorked = corked
+}
+
 func (s *paSession) Release() {
 	s.logger.Debug("Releasing audio session")
 }
 
 func (s *paSession) String() string {
-	return fmt.Sprintf(sessionStringFormat, s.humanReadableDesc, s.GetVolume())
+	return fmt.Sprintf(sessionStringFormat, s.humanReadableDesc, s.GetVolume(), s.GetMute())
 }
 
 func (s *masterSession) GetVolume() float32 {
@@ -195,12 +318,78 @@ func (s *masterSession) SetVolume(v float32) error {
 	return nil
 }
 
+func (s *masterSession) GetMute() bool {
+	if s.isOutput {
+		request := proto.GetSinkInfo{
+			SinkIndex: s.streamIndex,
+		}
+		reply := proto.GetSinkInfoReply{}
+
+		if err := s.client.Request(&request, &reply); err != nil {
+			s.logger.Warnw("Failed to get session mute", "error", err)
+			return false
+		}
+
+		return reply.Mute
+	}
+
+	request := proto.GetSourceInfo{
+		SourceIndex: s.streamIndex,
+	}
+	reply := proto.GetSourceInfoReply{}
+
+	if err := s.client.Request(&request, &reply); err != nil {
+		s.logger.Warnw("Failed to get session mute", "error", err)
+		return false
+	}
+
+	return reply.Mute
+}
+
+func (s *masterSession) SetMute(m bool) error {
+	var request proto.RequestArgs
+
+	if s.isOutput {
+		request = &proto.SetSinkMute{
+			SinkIndex: s.streamIndex,
+			Mute:      m,
+		}
+	} else {
+		request = &proto.SetSourceMute{
+			SourceIndex: s.streamIndex,
+			Mute:        m,
+		}
+	}
+
+	if err := s.client.Request(request, nil); err != nil {
+		s.logger.Warnw("Failed to set session mute",
+			"error", err,
+			"mute", m)
+
+		return fmt.Errorf("adjust session mute: %w", err)
+	}
+
+	s.logger.Debugw("Adjusting session mute", "to", m)
+
+	return nil
+}
+
+// deviceNames lets device sessions be targeted by their sink/source name (e.g. "alsa_output.pci-0000_00_1f.3.analog-stereo")
+// as well as their description
+func (s *masterSession) deviceNames() []string {
+	if !s.device {
+		return nil
+	}
+
+	return []string{s.Key(), strings.ToLower(s.deviceName)}
+}
+
 func (s *masterSession) Release() {
 	s.logger.Debug("Releasing audio session")
 }
 
 func (s *masterSession) String() string {
-	return fmt.Sprintf(sessionStringFormat, s.humanReadableDesc, s.GetVolume())
+	return fmt.Sprintf(sessionStringFormat, s.humanReadableDesc, s.GetVolume(), s.GetMute())
 }
 
 func createChannelVolumes(channels byte, volume float32) []uint32 {
@@ -2,6 +2,7 @@ package deej
 
 import (
 	"fmt"
+	"math"
 	"regexp"
 	"strings"
 	"sync"
@@ -19,15 +20,54 @@ type sessionMap struct {
 	m    map[string][]Session
 	lock sync.Locker
 
+	// sessions by their stream properties, for "prop:" targets
+	propertyIndex *propertyIndex
+
 	sessionFinder SessionFinder
 
+	// set when the session finder reports session changes by itself, so there's no need to poll for them
+	eventDriven bool
+
 	lastSessionRefresh time.Time
-	unmappedSessions   []Session
+
+	// sessions that no slider maps, for "deej.unmapped". guarded by the map's lock, since sessions come
+	// and go on the session finder's goroutine while sliders read this on theirs
+	unmappedSessions []Session
+
+	// the target each fallback slider currently controls, used to log whenever it changes
+	fallbackTargets *fallbackTargets
+
+	// volumes of locked sliders' targets, keyed by slider and target
+	volumeLocks *volumeLocks
+
+	freeze *freezeState
+	solo   *soloState
+
+	pushToTalk *pushToTalkState
+
+	startupSync *startupSyncState
+
+	// the last value of each slider, as received from the hardware
+	sliderValues     map[int]float32
+	sliderValuesLock sync.Locker
+
+	activeSchedules []*schedule
+	schedulesLock   sync.Locker
+
+	// slider events that were held back for a while (i.e. by a button gesture) and should now be applied
+	delayedSliderEvents chan SliderEvent
 
 	ticker     *time.Ticker
 	tickerDone chan (bool)
 }
 
+// fallbackTargets has its own lock, since sliders are adjusted from several goroutines
+// (slider events, schedules, session changes)
+type fallbackTargets struct {
+	m    map[int]string
+	lock sync.Locker
+}
+
 const (
 	masterSessionName = "master" // master device volume
 	systemSessionName = "system" // system sounds volume
@@ -37,12 +77,15 @@ const (
 	// this prefix identifies those targets to ensure they don't contradict with another similarly-named process
 	specialTargetTransformPrefix = "deej."
 
-	// targets the currently active window (Windows-only, experimental)
+	// targets the currently active window (experimental)
 	specialTargetCurrentWindow = "current"
 
 	// targets all currently unmapped sessions (experimental)
 	specialTargetAllUnmapped = "unmapped"
 
+	// targets all sessions that are currently producing audio
+	specialTargetPlaying = "playing"
+
 	// this threshold constant assumes that re-acquiring all sessions is a kind of expensive operation,
 	// and needs to be limited in some manner. this value was previously user-configurable through a config
 	// key "process_refresh_frequency", but exposing this type of implementation detail seems wrong now
@@ -52,8 +95,8 @@ const (
 	// this is a bit greedy but allows us to ensure sessions are always re-acquired, which is
 	// especially important for process groups (because you can have one ongoing session
 	// always preventing lookup of other processes bound to its slider, which forces the user
-	// to manually refresh sessions). a cleaner way to do this down the line is by registering to notifications
-	// whenever a new session is added, but that's too hard to justify for how easy this solution is
+	// to manually refresh sessions). session finders that report new sessions by themselves (see SessionNotifier)
+	// don't need this, so it only applies when polling
 	maxTimeBetweenSessionRefreshes = time.Second * 45
 )
 
@@ -64,12 +107,23 @@ func newSessionMap(deej *Deej, logger *zap.SugaredLogger, sessionFinder SessionF
 	logger = logger.Named("sessions")
 
 	m := &sessionMap{
-		deej:          deej,
-		logger:        logger,
-		m:             make(map[string][]Session),
-		lock:          &sync.Mutex{},
-		sessionFinder: sessionFinder,
-		tickerDone:    make(chan (bool)),
+		deej:                deej,
+		logger:              logger,
+		m:                   make(map[string][]Session),
+		propertyIndex:       newPropertyIndex(nil),
+		lock:                &sync.Mutex{},
+		sessionFinder:       sessionFinder,
+		fallbackTargets:     newFallbackTargets(),
+		volumeLocks:         newVolumeLocks(),
+		freeze:              newFreezeState(),
+		solo:                newSoloState(),
+		pushToTalk:          newPushToTalkState(),
+		startupSync:         newStartupSyncState(),
+		sliderValues:        make(map[int]float32),
+		sliderValuesLock:    &sync.Mutex{},
+		schedulesLock:       &sync.Mutex{},
+		delayedSliderEvents: make(chan SliderEvent),
+		tickerDone:          make(chan (bool)),
 	}
 
 	logger.Debug("Created session map instance")
@@ -78,13 +132,19 @@ func newSessionMap(deej *Deej, logger *zap.SugaredLogger, sessionFinder SessionF
 }
 
 func (m *sessionMap) initialize() error {
-	if err := m.getAndAddSessions(); err != nil {
+	// the map was created before the config was loaded, so it doesn't know which properties to index yet
+	m.rebuildPropertyIndex()
+
+	if err := m.getAndAddSessions(nil); err != nil {
 		m.logger.Warnw("Failed to get all sessions during session map initialization", "error", err)
 		return fmt.Errorf("get all sessions during init: %w", err)
 	}
 
+	m.initializePushToTalk()
+
 	m.setupOnConfigReload()
 	m.setupOnSliderMove()
+	m.setupOnSessionChanges()
 
 	m.ticker = time.NewTicker(time.Second)
 
@@ -94,7 +154,11 @@ func (m *sessionMap) initialize() error {
 			case <-m.tickerDone:
 				return
 			case <-m.ticker.C:
-				m.refreshSessions(false)
+				if !m.eventDriven {
+					m.refreshSessions(false)
+				}
+
+				m.enforceVolumeLocks()
 			}
 		}
 	}()
@@ -115,11 +179,16 @@ func (m *sessionMap) release() error {
 }
 
 // assumes the session map is clean!
-// only call on a new session map or as part of refreshSessions which calls reset
-func (m *sessionMap) getAndAddSessions() error {
+// only call on a new session map or as part of refreshSessions which calls reset.
+// knownKeys are the session keys that were in the map before it was cleared, so that only sessions
+// that are actually new get treated as such
+func (m *sessionMap) getAndAddSessions(knownKeys map[string]bool) error {
 	// mark that we're refreshing before anything else
 	m.lastSessionRefresh = time.Now()
+
+	m.lock.Lock()
 	m.unmappedSessions = nil
+	m.lock.Unlock()
 
 	sessions, err := m.sessionFinder.GetAllSessions()
 	if err != nil {
@@ -127,15 +196,41 @@ func (m *sessionMap) getAndAddSessions() error {
 		return fmt.Errorf("get sessions from SessionFinder: %w", err)
 	}
 
+	addedSessions := []Session{}
+	discoveredSessions := []Session{}
+
 	for _, session := range sessions {
+
+		// ignored sessions never make it into the map, so nothing (including deej.unmapped) can touch them
+		if m.sessionIgnored(session) {
+			m.logger.Debugw("Ignoring session", "session", session)
+			session.Release()
+
+			continue
+		}
+
 		m.add(session)
+		addedSessions = append(addedSessions, session)
+
+		if !knownKeys[session.Key()] {
+			discoveredSessions = append(discoveredSessions, session)
+		}
 
 		if !m.sessionMapped(session) {
-			m.logger.Debugw("Tracking unmapped session", "session", session)
-			m.unmappedSessions = append(m.unmappedSessions, session)
+			m.trackUnmappedSession(session)
 		}
 	}
 
+	// sessions that appeared during a solo need to be muted as well, and the mic (which may now be
+	// another device) has to follow push-to-talk
+	for _, session := range addedSessions {
+		m.applySoloToNewSession(session)
+		m.applyPushToTalkToNewSession(session)
+	}
+
+	// relaunched apps get their slider's volume right away, instead of on its next move
+	m.applyRememberedVolumes(discoveredSessions)
+
 	m.logger.Infow("Got all audio sessions successfully", "sessionMap", m)
 
 	return nil
@@ -149,6 +244,9 @@ func (m *sessionMap) setupOnConfigReload() {
 			select {
 			case <-configReloadedChannel:
 				m.logger.Info("Detected config reload, attempting to re-acquire all audio sessions")
+
+				// the refresh might be skipped if one just happened, but the properties to index could've changed
+				m.rebuildPropertyIndex()
 				m.refreshSessions(false)
 			}
 		}
@@ -163,11 +261,105 @@ func (m *sessionMap) setupOnSliderMove() {
 			select {
 			case event := <-sliderEventsChannel:
 				m.handleSliderEvent(event)
+			case event := <-m.delayedSliderEvents:
+				m.applySliderEvent(event)
 			}
 		}
 	}()
 }
 
+// setupOnSessionChanges keeps the map up to date as sessions come and go, if the session finder can report that
+func (m *sessionMap) setupOnSessionChanges() {
+	notifier, ok := m.sessionFinder.(SessionNotifier)
+	if !ok {
+		return
+	}
+
+	m.logger.Debug("Session finder reports session changes, no need to poll for them")
+	m.eventDriven = true
+
+	sessionChangesChannel := notifier.SubscribeToSessionChanges()
+
+	go func() {
+		for {
+			select {
+			case change := <-sessionChangesChannel:
+				m.handleSessionChange(change)
+			}
+		}
+	}()
+}
+
+func (m *sessionMap) handleSessionChange(change SessionChange) {
+	if change.Refresh {
+		m.refreshSessions(true)
+		return
+	}
+
+	if change.Removed {
+		m.logger.Debugw("Session removed", "session", change.Session)
+		m.remove(change.Session)
+
+		return
+	}
+
+	// a session's properties may have changed such that it's now ignored (or no longer is, in which case it's added below)
+	if m.sessionIgnored(change.Session) {
+		if m.contains(change.Session) {
+			m.logger.Debugw("Ignoring session", "session", change.Session)
+			m.remove(change.Session)
+		}
+
+		return
+	}
+
+	if change.Changed && m.contains(change.Session) {
+		m.logger.Debugw("Session changed", "session", change.Session)
+		m.reindexSession(change.Session)
+
+		return
+	}
+
+	m.logger.Debugw("Session added", "session", change.Session)
+	m.add(change.Session)
+
+	if !m.sessionMapped(change.Session) {
+		m.trackUnmappedSession(change.Session)
+	}
+
+	// this is also how master and mic sessions arrive after the default devices changed,
+	// so a new mic has to pick up push-to-talk's current state
+	m.applySoloToNewSession(change.Session)
+	m.applyPushToTalkToNewSession(change.Session)
+	m.applyRememberedVolumes([]Session{change.Session})
+
+	// event sounds are usually over before a slider could move, so they get the system slider's value right away
+	if change.Session.Key() == systemSessionName {
+		m.reapplySlidersForTarget(systemSessionName)
+	}
+}
+
+// reapplySlidersForTarget sets every slider that controls the given target according to its last known value
+func (m *sessionMap) reapplySlidersForTarget(target string) {
+	sliderValues := make(map[int]float32)
+
+	m.deej.config.SliderMapping().iterate(func(sliderIdx int, targets []string) {
+		for _, sliderTarget := range targets {
+			if funk.ContainsString(m.resolveTarget(sliderTarget), target) {
+				if value, ok := m.getSliderValue(sliderIdx); ok {
+					sliderValues[sliderIdx] = value
+				}
+
+				return
+			}
+		}
+	})
+
+	for sliderIdx, value := range sliderValues {
+		m.adjustSlider(sliderIdx, value, false)
+	}
+}
+
 // performance: explain why force == true at every such use to avoid unintended forced refresh spams
 func (m *sessionMap) refreshSessions(force bool) {
 	// make sure enough time passed since the last refresh, unless force is true in which case always clear
@@ -175,10 +367,15 @@ func (m *sessionMap) refreshSessions(force bool) {
 		return
 	}
 
+	knownKeys := make(map[string]bool)
+	for _, session := range m.allSessions() {
+		knownKeys[session.Key()] = true
+	}
+
 	// clear and release sessions first
 	m.clear()
 
-	if err := m.getAndAddSessions(); err != nil {
+	if err := m.getAndAddSessions(knownKeys); err != nil {
 		m.logger.Warnw("Failed to re-acquire all audio sessions", "error", err)
 	} else {
 		m.logger.Debug("Re-acquired sessions successfully")
@@ -195,25 +392,56 @@ func (m *sessionMap) sessionMapped(session Session) bool {
 	}
 
 	// count device sessions as mapped
-	if deviceSessionKeyPattern.MatchString(session.Key()) {
+	if m.isDeviceSession(session) {
 		return true
 	}
 
 	matchFound := false
+	sliderMapping := m.deej.config.SliderMapping()
 
 	// look through the actual mappings
-	m.deej.config.SliderMapping.iterate(func(sliderIdx int, targets []string) {
+	sliderMapping.iterate(func(sliderIdx int, targets []string) {
+
+		// a slider doesn't map the sessions it excludes, no matter what its targets match
+		if m.sessionExcluded(sliderMapping.options[sliderIdx], session) {
+			return
+		}
+
 		for _, target := range targets {
 
+			// an app counts as mapped to its "active:" target even while it's quiet
+			if innerTarget, ok := parseActiveTarget(target); ok {
+				target = innerTarget
+			}
+
 			// ignore special transforms
 			if m.targetHasSpecialTransform(target) {
 				continue
 			}
 
+			if propertyTarget, ok := parsePropertyTarget(target); ok {
+				if propertyTarget.matches(session) {
+					matchFound = true
+					return
+				}
+
+				continue
+			}
+
+			// a session matched by a regex or glob is just as mapped as one that's named explicitly
+			if pattern, ok := m.deej.config.targetPattern(target); ok {
+				if m.sessionMatchesPattern(session, pattern) {
+					matchFound = true
+					return
+				}
+
+				continue
+			}
+
 			// safe to assume this has a single element because we made sure there's no special transform
 			target = m.resolveTarget(target)[0]
 
-			if target == session.Key() {
+			if target == session.Key() || funk.ContainsString(sessionNames(session), target) {
 				matchFound = true
 				return
 			}
@@ -224,31 +452,43 @@ func (m *sessionMap) sessionMapped(session Session) bool {
 }
 
 func (m *sessionMap) getCurrentVolume(sliderIdx int) float32 {
-	targets, ok := m.deej.config.SliderMapping.get(sliderIdx)
+	sliderMapping := m.deej.config.SliderMapping()
+
+	targets, ok := sliderMapping.get(sliderIdx)
 
 	if !ok {
 		m.logger.Warnw("SessionMap getCurrentVolume: couldn't find mapping for slider", "sliderIdx", sliderIdx)
 		return -1
 	}
 
-	for _, target := range targets {
+	options, _ := sliderMapping.getOptions(sliderIdx)
 
-		// resolve the target name by cleaning it up and applying any special transformations.
-		// depending on the transformation applied, this can result in more than one target name
-		resolvedTargets := m.resolveTarget(target)
+	// a crossfade slider's position can be recovered from the volume of its second group
+	if options != nil && options.crossfade != nil {
+		for _, target := range options.crossfade.groupB {
+			if sessions := m.sliderTargetSessions(options, target); len(sessions) > 0 {
+				return options.crossfade.position(m.sliderValueFromVolume(options, target, sessions[0].GetVolume()))
+			}
+		}
 
-		// for each resolved target...
-		for _, resolvedTarget := range resolvedTargets {
+		m.logger.Warnw("SessionMap getCurrentVolume: couldn't find a crossfade session. returning -1", "sliderIdx", sliderIdx)
+		return -1
+	}
 
-			// check the map for matching sessions
-			sessions, ok := m.get(resolvedTarget)
+	// the first target with any sessions is the one we report. for fallback sliders, this is also
+	// exactly the target that's currently being controlled
+	for _, target := range targets {
+		if sessions := m.sliderTargetSessions(options, target); len(sessions) > 0 {
+			value := m.sliderValueFromVolume(options, target, sessions[0].GetVolume())
 
-			// no sessions matching this target - move on
-			if !ok {
-				continue
+			// undo the master's part in relative sliders, too
+			if options != nil && options.relative && sliderIdx != m.masterSliderIdx() {
+				if masterValue := m.masterSliderValue(); masterValue > 0 {
+					value = float32(math.Min(float64(value/masterValue), 1))
+				}
 			}
 
-			return sessions[0].GetVolume()
+			return value
 		}
 	}
 
@@ -256,82 +496,405 @@ func (m *sessionMap) getCurrentVolume(sliderIdx int) float32 {
 	return -1
 }
 
+// sliderValueFromVolume undoes a target's modifier, if it has one
+func (m *sessionMap) sliderValueFromVolume(options *sliderOptions, target string, volume float32) float32 {
+	if modifier, ok := options.modifier(target); ok {
+		return modifier.revert(volume)
+	}
+
+	return volume
+}
+
 func (m *sessionMap) handleSliderEvent(event SliderEvent) {
-	// first of all, ensure our session map isn't moldy
-	if m.lastSessionRefresh.Add(maxTimeBetweenSessionRefreshes).Before(time.Now()) {
+	// button gestures may hold back the event's mute toggle for later
+	event = m.handleFreezeButton(event)
+	event = m.handleSoloButton(event)
+	event = m.handlePushToTalkButton(event)
+
+	m.applySliderEvent(event)
+}
+
+func (m *sessionMap) applySliderEvent(event SliderEvent) {
+	// hardware input is ignored while frozen, and for a little while after.
+	// sliders that are only waiting to be moved again still have working mute buttons, though
+	if discard, muteOnly := m.filterFrozenEvent(event); discard {
+		if muteOnly {
+			m.toggleSliderMute(event.SliderID)
+		}
+
+		return
+	}
+
+	// so are sliders that haven't been picked up (or moved) since connecting, whose mute buttons work regardless
+	if discard, muteOnly := m.filterStartupEvent(event); discard {
+		if muteOnly {
+			m.toggleSliderMute(event.SliderID)
+		}
+
+		return
+	}
+
+	// ensure our session map isn't moldy (unless it's kept up to date by the session finder)
+	if !m.eventDriven && m.lastSessionRefresh.Add(maxTimeBetweenSessionRefreshes).Before(time.Now()) {
 		m.logger.Debug("Stale session map detected on slider move, refreshing")
 		m.refreshSessions(true)
 	}
 
-	// get the targets mapped to this slider from the config
-	targets, ok := m.deej.config.SliderMapping.get(event.SliderID)
+	// remember where the slider is, for anything that needs to re-apply it later
+	m.setSliderValue(event.SliderID, event.PercentValue)
+	m.rememberSliderValue(event.SliderID, event.PercentValue)
+
+	targetFound, adjustmentFailed, mapped := m.adjustSlider(event.SliderID, event.PercentValue, event.ToggleMute)
 
 	// if slider not found in config, silently ignore
+	if !mapped {
+		return
+	}
+
+	// if we still haven't found a target or the volume adjustment failed, maybe look for the target again.
+	// processes could've opened since the last time this slider moved.
+	// if they haven't, the cooldown will take care to not spam it up
+	if !targetFound && !m.eventDriven {
+		m.refreshSessions(false)
+	} else if adjustmentFailed {
+		// performance: the reason that forcing a refresh here is okay is that we'll only get here
+		// when a session's SetVolume call errored, such as in the case of a stale master session
+		// (or another, more catastrophic failure happens)
+		m.refreshSessions(true)
+	}
+
+	// sliders relative to master need to follow it
+	if event.SliderID == m.masterSliderIdx() {
+		m.reapplyRelativeSliders()
+	}
+}
+
+// adjustSlider applies a slider's value to its targets, according to its options.
+// it reports whether any matching session was found, whether any of the adjustments failed,
+// and whether the slider is mapped at all
+func (m *sessionMap) adjustSlider(sliderIdx int, value float32, toggleMute bool) (bool, bool, bool) {
+	sliderMapping := m.deej.config.SliderMapping()
+
+	// get the targets mapped to this slider from the config
+	targets, ok := sliderMapping.get(sliderIdx)
+	if !ok {
+		return false, false, false
+	}
+
+	var targetFound, adjustmentFailed bool
+
+	options, _ := sliderMapping.getOptions(sliderIdx)
+
+	// relative sliders represent a fraction of the master slider
+	if options != nil && options.relative && sliderIdx != m.masterSliderIdx() {
+		value *= m.masterSliderValue()
+	}
+
+	// crossfade sliders split their value between two groups, each getting its own complementary volume
+	if options != nil && options.crossfade != nil {
+		gainA, gainB := options.crossfade.gains(value)
+
+		foundA, failedA := m.applyToTargets(sliderIdx, options.crossfade.groupA, options, gainA, toggleMute)
+		foundB, failedB := m.applyToTargets(sliderIdx, options.crossfade.groupB, options, gainB, toggleMute)
+
+		targetFound = foundA || foundB
+		adjustmentFailed = failedA || failedB
+	} else if options != nil && options.fallback {
+
+		// fallback sliders only control the first of their targets that's currently available
+		if target, ok := m.firstAvailableTarget(options, targets); ok {
+			m.logFallbackTarget(sliderIdx, target)
+
+			// the targets it fell back from aren't this slider's to enforce anymore
+			if options.lock {
+				m.volumeLocks.retain(sliderIdx, []string{target})
+			}
+
+			targetFound, adjustmentFailed = m.applyToTargets(sliderIdx, []string{target}, options, value, toggleMute)
+		}
+	} else {
+		targetFound, adjustmentFailed = m.applyToTargets(sliderIdx, targets, options, value, toggleMute)
+	}
+
+	return targetFound, adjustmentFailed, true
+}
+
+// toggleSliderMute toggles the mute of a slider's targets without touching their volume,
+// for mute presses on sliders whose value is being held back
+func (m *sessionMap) toggleSliderMute(sliderIdx int) {
+	sliderMapping := m.deej.config.SliderMapping()
+
+	targets, ok := sliderMapping.get(sliderIdx)
 	if !ok {
 		return
 	}
 
+	options, _ := sliderMapping.getOptions(sliderIdx)
+
+	if options != nil && options.crossfade != nil {
+		targets = append(append([]string{}, options.crossfade.groupA...), options.crossfade.groupB...)
+	} else if options != nil && options.fallback {
+		target, ok := m.firstAvailableTarget(options, targets)
+		if !ok {
+			return
+		}
+
+		targets = []string{target}
+	}
+
+	toggled := make(map[Session]bool)
+
+	for _, target := range targets {
+		for _, session := range m.sliderTargetSessions(options, target) {
+			if toggled[session] {
+				continue
+			}
+
+			toggled[session] = true
+
+			if err := session.SetMute(!session.GetMute()); err != nil {
+				m.logger.Warnw("Failed to set target session mute", "error", err)
+			}
+		}
+	}
+}
+
+// applyToTargets sets the volume of every session matching the given targets (and toggles their mute, if asked).
+// targets with a modifier in the slider's options follow the volume at their own relative level.
+// it reports whether any matching session was found, and whether any of the adjustments failed
+func (m *sessionMap) applyToTargets(
+	sliderIdx int,
+	targets []string,
+	options *sliderOptions,
+	volume float32,
+	toggleMute bool,
+) (bool, bool) {
 	targetFound := false
 	adjustmentFailed := false
 
+	locked := options != nil && options.lock
+
 	// for each possible target for this slider...
 	for _, target := range targets {
 
-		// resolve the target name by cleaning it up and applying any special transformations.
-		// depending on the transformation applied, this can result in more than one target name
-		resolvedTargets := m.resolveTarget(target)
+		targetVolume := volume
+		if modifier, ok := options.modifier(target); ok {
+			targetVolume = modifier.apply(volume)
+		}
 
-		// for each resolved target...
-		for _, resolvedTarget := range resolvedTargets {
+		// remember what we set, so it can be enforced if the target's apps change it on their own
+		if locked {
+			m.volumeLocks.set(sliderIdx, target, targetVolume)
+		}
 
-			// check the map for matching sessions
-			sessions, ok := m.get(resolvedTarget)
+		sessions := m.sliderTargetSessions(options, target)
 
-			// no sessions matching this target - move on
-			if !ok {
-				continue
-			}
+		// no sessions matching this target - move on
+		if len(sessions) == 0 {
+			continue
+		}
 
-			targetFound = true
+		targetFound = true
 
-			// iterate all matching sessions and adjust the volume of each one
-			for _, session := range sessions {
-				if target == specialTargetTransformPrefix+specialTargetCurrentWindow {
-					if m.sessionMapped(session) {
-						continue
-					}
+		// iterate all matching sessions and adjust the volume of each one
+		for _, session := range sessions {
+			sessionVolume := m.scheduledVolume(session, targetVolume)
+
+			if session.GetVolume() != sessionVolume {
+				if err := session.SetVolume(sessionVolume); err != nil {
+					m.logger.Warnw("Failed to set target session volume", "error", err)
+					adjustmentFailed = true
 				}
+			}
+
+			if toggleMute {
+				sessionMute := session.GetMute()
 
-				if session.GetVolume() != event.PercentValue {
-					if err := session.SetVolume(event.PercentValue); err != nil {
-						m.logger.Warnw("Failed to set target session volume", "error", err)
-						adjustmentFailed = true
-					}
+				if err := session.SetMute(!sessionMute); err != nil {
+					m.logger.Warnw("Failed to set target session mute", "error", err)
+					adjustmentFailed = true
 				}
+			}
+		}
+	}
+
+	return targetFound, adjustmentFailed
+}
 
-				if event.ToggleMute {
-					sessionMute := session.GetMute()
+// targetSessions returns all sessions currently matching a single (unresolved) target
+func (m *sessionMap) targetSessions(target string) []Session {
+	result := []Session{}
 
-					if err := session.SetMute(!sessionMute); err != nil {
-						m.logger.Warnw("Failed to set target session mute", "error", err)
-						adjustmentFailed = true
-					}
+	// "active:" targets are whatever their inner target is, as long as it's audible
+	if innerTarget, ok := parseActiveTarget(target); ok {
+		return m.playingSessions(m.targetSessions(innerTarget))
+	}
+
+	// property targets don't correspond to any session key, so they're looked up by property instead
+	if propertyTarget, ok := parsePropertyTarget(target); ok {
+		return m.propertyTargetSessions(propertyTarget)
+	}
+
+	// resolve the target name by cleaning it up and applying any special transformations.
+	// depending on the transformation applied, this can result in more than one target name
+	resolvedTargets := m.resolveTarget(target)
+
+	// for each resolved target...
+	for _, resolvedTarget := range resolvedTargets {
+
+		// check the map for matching sessions
+		sessions, ok := m.get(resolvedTarget)
+
+		// no sessions matching this target - move on
+		if !ok {
+			continue
+		}
+
+		for _, session := range sessions {
+
+			// the current window only counts when it isn't already controlled by another slider
+			if strings.ToLower(target) == specialTargetTransformPrefix+specialTargetCurrentWindow {
+				if m.sessionMapped(session) {
+					continue
+				}
+			}
+
+			// an app can have idle sessions next to the one that's playing (i.e. browser tabs), so check each of them
+			if strings.ToLower(target) == specialTargetTransformPrefix+specialTargetPlaying {
+				if !m.sessionPlaying(session) {
+					continue
 				}
 			}
+
+			result = append(result, session)
 		}
 	}
 
-	// if we still haven't found a target or the volume adjustment failed, maybe look for the target again.
-	// processes could've opened since the last time this slider moved.
-	// if they haven't, the cooldown will take care to not spam it up
-	if !targetFound {
-		m.refreshSessions(false)
-	} else if adjustmentFailed {
-		// performance: the reason that forcing a refresh here is okay is that we'll only get here
-		// when a session's SetVolume call errored, such as in the case of a stale master session
-		// (or another, more catastrophic failure happens)
-		m.refreshSessions(true)
+	return result
+}
+
+// sessionMatchesTarget returns true if the given session is one of the target's sessions
+func (m *sessionMap) sessionMatchesTarget(session Session, target string) bool {
+	if innerTarget, ok := parseActiveTarget(target); ok {
+		return m.sessionPlaying(session) && m.sessionMatchesTarget(session, innerTarget)
+	}
+
+	if strings.ToLower(target) == specialTargetTransformPrefix+specialTargetPlaying && !m.sessionPlaying(session) {
+		return false
+	}
+
+	if propertyTarget, ok := parsePropertyTarget(target); ok {
+		return propertyTarget.matches(session)
+	}
+
+	if pattern, ok := m.deej.config.targetPattern(target); ok {
+		return m.sessionMatchesPattern(session, pattern)
+	}
+
+	for _, resolvedTarget := range m.resolveTarget(target) {
+		if resolvedTarget == session.Key() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// firstAvailableTarget returns the first of the given targets that currently has any (non-excluded) sessions
+func (m *sessionMap) firstAvailableTarget(options *sliderOptions, targets []string) (string, bool) {
+	for _, target := range targets {
+		if len(m.sliderTargetSessions(options, target)) > 0 {
+			return target, true
+		}
+	}
+
+	return "", false
+}
+
+// enforceVolumeLocks re-applies locked volumes to sessions that have drifted away from them for long enough.
+// each lock's target is resolved again here, so dynamic targets apply to whichever sessions they match right now
+func (m *sessionMap) enforceVolumeLocks() {
+	now := time.Now()
+
+	m.volumeLocks.iterate(func(lock *volumeLock) {
+
+		// the slider may have been unlocked (or unmapped) by a config reload since
+		options, ok := m.deej.config.SliderMapping().getOptions(lock.sliderIdx)
+		if !ok || !options.lock {
+			m.volumeLocks.delete(lock)
+			return
+		}
+
+		driftedSessions := []Session{}
+
+		for _, session := range m.sliderTargetSessions(options, lock.target) {
+			if math.Abs(float64(session.GetVolume()-m.scheduledVolume(session, lock.volume))) > volumeLockTolerance {
+				driftedSessions = append(driftedSessions, session)
+			}
+		}
+
+		if len(driftedSessions) == 0 {
+			lock.driftedAt = time.Time{}
+			return
+		}
+
+		// give the app a grace period before fighting it
+		if lock.driftedAt.IsZero() {
+			lock.driftedAt = now
+			return
+		}
+
+		if lock.driftedAt.Add(volumeLockGracePeriod).After(now) {
+			return
+		}
+
+		for _, session := range driftedSessions {
+			lockedVolume := m.scheduledVolume(session, lock.volume)
+
+			m.logger.Infow("Session volume drifted from locked slider value, enforcing",
+				"session", session.Key(),
+				"target", lock.target,
+				"sliderIdx", lock.sliderIdx,
+				"actual", session.GetVolume(),
+				"locked", lockedVolume)
+
+			if err := session.SetVolume(lockedVolume); err != nil {
+				m.logger.Warnw("Failed to enforce locked session volume", "session", session.Key(), "error", err)
+			}
+		}
+
+		lock.driftedAt = time.Time{}
+	})
+}
+
+func newFallbackTargets() *fallbackTargets {
+	return &fallbackTargets{
+		m:    make(map[int]string),
+		lock: &sync.Mutex{},
+	}
+}
+
+// swap records the target a fallback slider now controls, and returns the one it controlled before
+// along with whether that's a change
+func (f *fallbackTargets) swap(sliderIdx int, target string) (string, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	previous := f.m[sliderIdx]
+	f.m[sliderIdx] = target
+
+	return previous, previous != target
+}
+
+func (m *sessionMap) logFallbackTarget(sliderIdx int, target string) {
+	if previous, changed := m.fallbackTargets.swap(sliderIdx, target); changed {
+		m.logger.Infow("Fallback slider resolved to a different target",
+			"sliderIdx", sliderIdx,
+			"from", previous,
+			"to", target)
 	}
+
+	m.logger.Debugw("Fallback slider controlling target", "sliderIdx", sliderIdx, "target", target)
 }
 
 func (m *sessionMap) targetHasSpecialTransform(target string) bool {
@@ -339,15 +902,73 @@ func (m *sessionMap) targetHasSpecialTransform(target string) bool {
 }
 
 func (m *sessionMap) resolveTarget(target string) []string {
-	// start by ignoring the case
+	// an "active:" target resolves to the same sessions as its inner target, but only the audible ones are used
+	if innerTarget, ok := parseActiveTarget(target); ok {
+		target = innerTarget
+	}
+
+	// regex and glob targets resolve to the keys of every session they currently match.
+	// they're looked up as written, since lowercasing a regex can change its meaning (i.e. \D becomes \d)
+	if !m.targetHasSpecialTransform(strings.ToLower(target)) {
+		if pattern, ok := m.deej.config.targetPattern(target); ok {
+			return m.matchingPatternKeys(pattern)
+		}
+	}
+
+	// otherwise, ignore the case
 	target = strings.ToLower(target)
 
-	// look for any special targets first, by examining the prefix
+	// look for any special targets, by examining the prefix
 	if m.targetHasSpecialTransform(target) {
 		return m.applyTargetTransform(strings.TrimPrefix(target, specialTargetTransformPrefix))
 	}
 
-	return []string{target}
+	// device and app sessions can also be targeted by their other names
+	return append([]string{target}, m.matchingNameKeys(target)...)
+}
+
+// isDeviceSession returns true if the session controls a specific audio device
+func (m *sessionMap) isDeviceSession(session Session) bool {
+	if device, ok := session.(deviceSession); ok && len(device.deviceNames()) > 0 {
+		return true
+	}
+
+	// windows device sessions are keyed by their friendly name
+	return deviceSessionKeyPattern.MatchString(session.Key())
+}
+
+// sessionNames returns the lowercase names a session can be targeted by besides its key:
+// a device's other names, or the other names of an app (see aliasedSession)
+func sessionNames(session Session) []string {
+	names := []string{}
+
+	if device, ok := session.(deviceSession); ok {
+		names = append(names, device.deviceNames()...)
+	}
+
+	if aliased, ok := session.(aliasedSession); ok {
+		names = append(names, aliased.aliases()...)
+	}
+
+	return names
+}
+
+// matchingNameKeys returns the keys of sessions that go by the given (lowercase) target under another name.
+// globs like "*usb*headset*" are handled along with all other pattern targets
+func (m *sessionMap) matchingNameKeys(target string) []string {
+	keys := []string{}
+
+	for _, session := range m.allSessions() {
+		if session.Key() == target {
+			continue
+		}
+
+		if funk.ContainsString(sessionNames(session), target) {
+			keys = append(keys, session.Key())
+		}
+	}
+
+	return funk.UniqString(keys)
 }
 
 func (m *sessionMap) applyTargetTransform(specialTargetName string) []string {
@@ -357,7 +978,7 @@ func (m *sessionMap) applyTargetTransform(specialTargetName string) []string {
 	// get current active window
 	case specialTargetCurrentWindow:
 		currentWindowProcessNames, err := util.GetCurrentWindowProcessNames()
-		// silently ignore errors here, as this is on deej's "hot path" (and it could just mean the user's running linux)
+		// silently ignore errors here, as this is on deej's "hot path" (and it could just mean there's no supported window manager)
 		if err != nil {
 			return nil
 		}
@@ -367,14 +988,20 @@ func (m *sessionMap) applyTargetTransform(specialTargetName string) []string {
 			currentWindowProcessNames[targetIdx] = strings.ToLower(target)
 		}
 
-		// remove dupes
-		return funk.UniqString(currentWindowProcessNames)
+		// remove dupes, and anything that's meant to be left alone
+		return m.withoutIgnoredProcessNames(funk.UniqString(currentWindowProcessNames))
+
+	// get sessions that are currently playing something
+	case specialTargetPlaying:
+		return m.playingSessionKeys()
 
 	// get currently unmapped sessions
 	case specialTargetAllUnmapped:
-		targetKeys := make([]string, len(m.unmappedSessions))
-		for sessionIdx, session := range m.unmappedSessions {
-			targetKeys[sessionIdx] = session.Key()
+		targetKeys := []string{}
+		for _, session := range m.unmappedSessionsSnapshot() {
+			if !m.sessionIgnored(session) {
+				targetKeys = append(targetKeys, session.Key())
+			}
 		}
 
 		return targetKeys
@@ -395,6 +1022,56 @@ func (m *sessionMap) add(value Session) {
 	} else {
 		m.m[key] = append(existing, value)
 	}
+
+	m.propertyIndex.index(value)
+}
+
+func (m *sessionMap) trackUnmappedSession(session Session) {
+	m.logger.Debugw("Tracking unmapped session", "session", session)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.unmappedSessions = append(m.unmappedSessions, session)
+}
+
+func (m *sessionMap) unmappedSessionsSnapshot() []Session {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return append([]Session{}, m.unmappedSessions...)
+}
+
+// remove takes a single session instance out of the map and releases it
+func (m *sessionMap) remove(value Session) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	key := value.Key()
+
+	remaining := []Session{}
+	for _, session := range m.m[key] {
+		if session != value {
+			remaining = append(remaining, session)
+		}
+	}
+
+	if len(remaining) > 0 {
+		m.m[key] = remaining
+	} else {
+		delete(m.m, key)
+	}
+
+	for idx, session := range m.unmappedSessions {
+		if session == value {
+			m.unmappedSessions = append(m.unmappedSessions[:idx], m.unmappedSessions[idx+1:]...)
+			break
+		}
+	}
+
+	m.propertyIndex.unindex(value)
+
+	value.Release()
 }
 
 func (m *sessionMap) get(key string) ([]Session, bool) {
@@ -405,6 +1082,32 @@ func (m *sessionMap) get(key string) ([]Session, bool) {
 	return value, ok
 }
 
+// contains returns true if this exact session is in the map
+func (m *sessionMap) contains(value Session) bool {
+	sessions, _ := m.get(value.Key())
+
+	for _, session := range sessions {
+		if session == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allSessions returns a snapshot of every session currently in the map
+func (m *sessionMap) allSessions() []Session {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	result := []Session{}
+	for _, sessions := range m.m {
+		result = append(result, sessions...)
+	}
+
+	return result
+}
+
 func (m *sessionMap) clear() {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -419,6 +1122,8 @@ func (m *sessionMap) clear() {
 		delete(m.m, key)
 	}
 
+	m.propertyIndex = newPropertyIndex(m.deej.config.PropertyTargetKeys)
+
 	m.logger.Debug("Session map cleared")
 }
 
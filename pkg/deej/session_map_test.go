@@ -0,0 +1,82 @@
+package deej
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// newTestSessionMap returns a session map over the given slider mapping (as it would appear in the config),
+// holding the given sessions
+func newTestSessionMap(t *testing.T, userMapping map[string]interface{}, sessions ...Session) *sessionMap {
+	logger := zap.NewNop().Sugar()
+
+	deej := &Deej{
+		logger: logger,
+		config: &CanonicalConfig{
+			logger:        logger,
+			sliderMapping: sliderMapFromConfigs(userMapping, nil),
+			mappingLock:   &sync.Mutex{},
+		},
+	}
+
+	m, err := newSessionMap(deej, logger, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deej.sessions = m
+
+	for _, session := range sessions {
+		m.add(session)
+	}
+
+	return m
+}
+
+func TestAdjustFallbackSlidersConcurrently(t *testing.T) {
+	fallback := map[string]interface{}{
+		sliderMappingKeyTargets: []interface{}{"missing", "spotify", "discord"},
+		sliderMappingKeyMode:    sliderModeFallback,
+	}
+
+	const sliderCount = 4
+
+	userMapping := map[string]interface{}{}
+	for sliderIdx := 0; sliderIdx < sliderCount; sliderIdx++ {
+		userMapping[strconv.Itoa(sliderIdx)] = fallback
+	}
+
+	m := newTestSessionMap(t, userMapping, &fakeSession{key: "spotify"}, &fakeSession{key: "discord"})
+
+	// sliders are adjusted from the slider event, scheduler and session change goroutines alike
+	wg := &sync.WaitGroup{}
+	start := make(chan bool)
+
+	for sliderIdx := 0; sliderIdx < sliderCount; sliderIdx++ {
+		wg.Add(1)
+
+		go func(sliderIdx int) {
+			defer wg.Done()
+			<-start
+
+			for i := 0; i < 500; i++ {
+				if found, _, mapped := m.adjustSlider(sliderIdx, 0.5, false); !found || !mapped {
+					t.Errorf("expected slider %d to find its fallback target", sliderIdx)
+					return
+				}
+			}
+		}(sliderIdx)
+	}
+
+	close(start)
+	wg.Wait()
+
+	for sliderIdx := 0; sliderIdx < sliderCount; sliderIdx++ {
+		if target, changed := m.fallbackTargets.swap(sliderIdx, "spotify"); changed {
+			t.Fatalf("expected slider %d to fall back to spotify, got %q", sliderIdx, target)
+		}
+	}
+}
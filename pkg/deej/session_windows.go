@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 
 	ole "github.com/go-ole/go-ole"
 	ps "github.com/mitchellh/go-ps"
@@ -25,6 +26,12 @@ type wcaSession struct {
 	control *wca.IAudioSessionControl2
 	volume  *wca.ISimpleAudioVolume
 
+	// may be nil, if the session doesn't support metering. the level meter reads it from its own goroutine,
+	// so it's guarded against being used after the session is released
+	meter     *iAudioMeterInformation
+	meterLock sync.Locker
+	released  bool
+
 	eventCtx *ole.GUID
 }
 
@@ -42,14 +49,17 @@ func newWCASession(
 	logger *zap.SugaredLogger,
 	control *wca.IAudioSessionControl2,
 	volume *wca.ISimpleAudioVolume,
+	meter *iAudioMeterInformation,
 	pid uint32,
 	eventCtx *ole.GUID,
 ) (*wcaSession, error) {
 	s := &wcaSession{
-		control:  control,
-		volume:   volume,
-		pid:      pid,
-		eventCtx: eventCtx,
+		control:   control,
+		volume:    volume,
+		meter:     meter,
+		meterLock: &sync.Mutex{},
+		pid:       pid,
+		eventCtx:  eventCtx,
 	}
 
 	// special treatment for system sounds session
@@ -177,15 +187,57 @@ func (s *wcaSession) SetVolume(v float32) error {
 	return nil
 }
 
+func (s *wcaSession) peak() (float32, bool) {
+	s.meterLock.Lock()
+	defer s.meterLock.Unlock()
+
+	if s.meter == nil || s.released {
+		return 0, false
+	}
+
+	var level float32
+
+	if err := s.meter.GetPeakValue(&level); err != nil {
+		s.logger.Debugw("Failed to get session peak", "error", err)
+		return 0, false
+	}
+
+	return level, true
+}
+
+// acquireMeter returns the session's meter with a reference of its own, which stays valid after the session
+// is released. the caller must release it when done
+func (s *wcaSession) acquireMeter() (*iAudioMeterInformation, bool) {
+	s.meterLock.Lock()
+	defer s.meterLock.Unlock()
+
+	if s.meter == nil || s.released {
+		return nil, false
+	}
+
+	s.meter.AddRef()
+
+	return s.meter, true
+}
+
 func (s *wcaSession) Release() {
 	s.logger.Debug("Releasing audio session")
 
 	s.volume.Release()
 	s.control.Release()
+
+	s.meterLock.Lock()
+	defer s.meterLock.Unlock()
+
+	s.released = true
+
+	if s.meter != nil {
+		s.meter.Release()
+	}
 }
 
 func (s *wcaSession) String() string {
-	return fmt.Sprintf(sessionStringFormat, s.humanReadableDesc, s.GetVolume())
+	return fmt.Sprintf(sessionStringFormat, s.humanReadableDesc, s.GetVolume(), s.GetMute())
 }
 
 func (s *masterSession) GetVolume() float32 {
@@ -253,7 +305,7 @@ func (s *masterSession) Release() {
 }
 
 func (s *masterSession) String() string {
-	return fmt.Sprintf(sessionStringFormat, s.humanReadableDesc, s.GetVolume())
+	return fmt.Sprintf(sessionStringFormat, s.humanReadableDesc, s.GetVolume(), s.GetMute())
 }
 
 func (s *masterSession) markAsStale() {
@@ -2,38 +2,125 @@ package deej
 
 import (
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 	"sync"
 
+	"github.com/spf13/cast"
 	"github.com/thoas/go-funk"
 )
 
 type sliderMap struct {
-	m    map[int][]string
-	lock sync.Locker
+	m       map[int][]string
+	options map[int]*sliderOptions
+	lock    sync.Locker
 }
 
+// sliderOptions holds any per-slider behavior that goes beyond a plain list of targets
+type sliderOptions struct {
+	crossfade *crossfadeMapping
+
+	// when set, only the first available target is controlled instead of all of them
+	fallback bool
+
+	// when set, volumes set by this slider are re-applied if the app changes them on its own
+	lock bool
+
+	// when set, the slider represents a fraction of the master slider rather than an absolute volume
+	relative bool
+
+	// when set, the slider's value is saved per target and applied to its sessions as soon as they appear
+	remember bool
+
+	// what to do with the slider's first value after connecting, overriding the default policy
+	startup string
+
+	// keyed by lowercase target name
+	modifiers map[string]targetModifier
+
+	// targets whose sessions this slider never touches, even if its other targets match them (e.g. "!obs.exe")
+	exclusions []string
+
+	// targets that were given as map keys (i.e. "vivaldi.exe: 0.5" as a list item), which viper lowercases
+	keyedTargets []string
+}
+
+// targetModifier makes a single target follow its slider at its own relative level,
+// e.g. "vivaldi.exe: 0.5" (half the slider's value) or "discord.exe: +10%" (10% above it)
+type targetModifier struct {
+	multiplier float32
+	offset     float32
+}
+
+// crossfadeMapping splits a single slider's value between two groups of targets,
+// such that one end of the slider is group A at full volume and the other is group B at full volume
+type crossfadeMapping struct {
+	groupA []string
+	groupB []string
+	law    string
+}
+
+const (
+	// keys available when a slider is mapped to a map instead of a target (or a list of targets)
+	sliderMappingKeyTargets   = "targets"
+	sliderMappingKeyMode      = "mode"
+	sliderMappingKeyLock      = "lock"
+	sliderMappingKeyRelative  = "relative"
+	sliderMappingKeyRemember  = "remember"
+	sliderMappingKeyStartup   = "startup"
+	sliderMappingKeyCrossfade = "crossfade"
+	crossfadeKeyGroupA        = "a"
+	crossfadeKeyGroupB        = "b"
+	crossfadeKeyLaw           = "law"
+
+	// keeps the perceived loudness constant across the slider's range (default)
+	crossfadeLawEqualPower = "equal_power"
+
+	// the two gains always add up to 1
+	crossfadeLawLinear = "linear"
+
+	// only the first target that currently exists gets adjusted, instead of all of them (the default)
+	sliderModeFallback = "fallback"
+
+	// marks a target as an exclusion rather than something to control
+	sliderExclusionPrefix = "!"
+)
+
 func newSliderMap() *sliderMap {
 	return &sliderMap{
-		m:    make(map[int][]string),
-		lock: &sync.Mutex{},
+		m:       make(map[int][]string),
+		options: make(map[int]*sliderOptions),
+		lock:    &sync.Mutex{},
 	}
 }
 
-func sliderMapFromConfigs(userMapping map[string][]string, internalMapping map[string][]string) *sliderMap {
+func newSliderOptions() *sliderOptions {
+	return &sliderOptions{
+		modifiers: make(map[string]targetModifier),
+	}
+}
+
+func sliderMapFromConfigs(userMapping map[string]interface{}, internalMapping map[string]interface{}) *sliderMap {
 	resultMap := newSliderMap()
 
 	// copy targets from user config, ignoring empty values
-	for sliderIdxString, targets := range userMapping {
+	for sliderIdxString, value := range userMapping {
 		sliderIdx, _ := strconv.Atoi(sliderIdxString)
 
+		targets, options := parseSliderMappingValue(value)
+
 		resultMap.set(sliderIdx, funk.FilterString(targets, func(s string) bool {
 			return s != ""
 		}))
+
+		if options != nil {
+			resultMap.setOptions(sliderIdx, options)
+		}
 	}
 
 	// add targets from internal configs, ignoring duplicate or empty values
-	for sliderIdxString, targets := range internalMapping {
+	for sliderIdxString, value := range internalMapping {
 		sliderIdx, _ := strconv.Atoi(sliderIdxString)
 
 		existingTargets, ok := resultMap.get(sliderIdx)
@@ -41,6 +128,8 @@ func sliderMapFromConfigs(userMapping map[string][]string, internalMapping map[s
 			existingTargets = []string{}
 		}
 
+		targets, _ := parseSliderMappingValue(value)
+
 		filteredTargets := funk.FilterString(targets, func(s string) bool {
 			return (!funk.ContainsString(existingTargets, s)) && s != ""
 		})
@@ -52,6 +141,208 @@ func sliderMapFromConfigs(userMapping map[string][]string, internalMapping map[s
 	return resultMap
 }
 
+// parseSliderMappingValue accepts a single target, a list of targets or a map describing
+// a more complex mapping, and returns the slider's targets alongside its options (if it has any)
+func parseSliderMappingValue(value interface{}) ([]string, *sliderOptions) {
+	options := newSliderOptions()
+
+	mapValue, err := cast.ToStringMapE(value)
+	if err != nil {
+		targets := options.parseTargets(value)
+
+		// plain targets only need options when some of them carry modifiers or exclusions
+		if len(options.modifiers) == 0 && len(options.exclusions) == 0 {
+			return targets, nil
+		}
+
+		return targets, options
+	}
+
+	targets := options.parseTargets(mapValue[sliderMappingKeyTargets])
+
+	options.fallback = strings.ToLower(cast.ToString(mapValue[sliderMappingKeyMode])) == sliderModeFallback
+	options.lock = cast.ToBool(mapValue[sliderMappingKeyLock])
+	options.relative = cast.ToBool(mapValue[sliderMappingKeyRelative])
+	options.remember = cast.ToBool(mapValue[sliderMappingKeyRemember])
+
+	if startupValue, ok := mapValue[sliderMappingKeyStartup]; ok {
+		if policy, valid := parseStartupSyncPolicy(cast.ToString(startupValue)); valid {
+			options.startup = policy
+		}
+	}
+
+	if crossfadeValue, ok := mapValue[sliderMappingKeyCrossfade]; ok {
+		crossfadeMap := cast.ToStringMap(crossfadeValue)
+
+		options.crossfade = &crossfadeMapping{
+			groupA: options.parseTargets(crossfadeMap[crossfadeKeyGroupA]),
+			groupB: options.parseTargets(crossfadeMap[crossfadeKeyGroupB]),
+			law:    strings.ToLower(cast.ToString(crossfadeMap[crossfadeKeyLaw])),
+		}
+
+		if options.crossfade.law != crossfadeLawLinear {
+			options.crossfade.law = crossfadeLawEqualPower
+		}
+	}
+
+	// a crossfade slider is considered mapped to both of its groups
+	if options.crossfade != nil {
+		targets = append(targets, options.crossfade.groupA...)
+		targets = append(targets, options.crossfade.groupB...)
+	}
+
+	return targets, options
+}
+
+// parseTargets reads either a single target or a list of them. each target may carry a modifier,
+// either as a single-key map ("vivaldi.exe: 0.5" as a list item) or inline ("discord.exe: +10%" as a string).
+// found modifiers are added to the options, and the bare target names are returned.
+// targets starting with "!" are exclusions - they're added to the options instead of being returned
+func (o *sliderOptions) parseTargets(value interface{}) []string {
+	var items []interface{}
+
+	switch typedValue := value.(type) {
+	case nil:
+		return []string{}
+	case []interface{}:
+		items = typedValue
+	default:
+		items = []interface{}{typedValue}
+	}
+
+	targets := make([]string, 0, len(items))
+
+	for _, item := range items {
+		if itemMap, err := cast.ToStringMapE(item); err == nil {
+			for target, modifierValue := range itemMap {
+				if modifier, ok := parseTargetModifier(modifierValue); ok {
+					o.modifiers[strings.ToLower(target)] = modifier
+				}
+
+				targets = append(targets, target)
+				o.keyedTargets = append(o.keyedTargets, target)
+			}
+
+			continue
+		}
+
+		target := cast.ToString(item)
+
+		if strings.HasPrefix(target, sliderExclusionPrefix) {
+			if exclusion := strings.TrimSpace(strings.TrimPrefix(target, sliderExclusionPrefix)); exclusion != "" {
+				o.exclusions = append(o.exclusions, exclusion)
+			}
+
+			continue
+		}
+
+		if separatorIdx := strings.LastIndex(target, ":"); separatorIdx > 0 {
+			if modifier, ok := parseTargetModifier(target[separatorIdx+1:]); ok {
+				target = strings.TrimSpace(target[:separatorIdx])
+				o.modifiers[strings.ToLower(target)] = modifier
+			}
+		}
+
+		targets = append(targets, target)
+	}
+
+	return targets
+}
+
+// parseTargetModifier reads a plain multiplier (0.5), a percentage of the slider's value (50%)
+// or a signed percentage offset from it (+10%, -10%)
+func parseTargetModifier(value interface{}) (targetModifier, bool) {
+	modifierString := strings.TrimSpace(cast.ToString(value))
+
+	if strings.HasSuffix(modifierString, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(modifierString, "%"), 32)
+		if err != nil {
+			return targetModifier{}, false
+		}
+
+		if strings.HasPrefix(modifierString, "+") || strings.HasPrefix(modifierString, "-") {
+			return targetModifier{multiplier: 1, offset: float32(percent / 100)}, true
+		}
+
+		return targetModifier{multiplier: float32(percent / 100)}, true
+	}
+
+	multiplier, err := strconv.ParseFloat(modifierString, 32)
+	if err != nil || multiplier < 0 {
+		return targetModifier{}, false
+	}
+
+	return targetModifier{multiplier: float32(multiplier)}, true
+}
+
+// apply returns the volume this modifier's target should have for the given slider value
+func (t targetModifier) apply(value float32) float32 {
+	value = value*t.multiplier + t.offset
+
+	if value < 0 {
+		return 0
+	} else if value > 1 {
+		return 1
+	}
+
+	return value
+}
+
+// revert recovers the slider value from a target's current volume
+func (t targetModifier) revert(volume float32) float32 {
+	if t.multiplier == 0 {
+		return volume
+	}
+
+	value := (volume - t.offset) / t.multiplier
+
+	if value < 0 {
+		return 0
+	} else if value > 1 {
+		return 1
+	}
+
+	return value
+}
+
+// modifier returns the modifier configured for the given target, if there's one
+func (o *sliderOptions) modifier(target string) (targetModifier, bool) {
+	if o == nil {
+		return targetModifier{}, false
+	}
+
+	modifier, ok := o.modifiers[strings.ToLower(target)]
+	return modifier, ok
+}
+
+// excludedTargets returns the slider's exclusions, if it has any
+func (o *sliderOptions) excludedTargets() []string {
+	if o == nil {
+		return nil
+	}
+
+	return o.exclusions
+}
+
+// gains returns the volume of group A and group B for the given slider position
+func (c *crossfadeMapping) gains(position float32) (float32, float32) {
+	if c.law == crossfadeLawLinear {
+		return 1 - position, position
+	}
+
+	angle := float64(position) * math.Pi / 2
+	return float32(math.Cos(angle)), float32(math.Sin(angle))
+}
+
+// position is the inverse of gains, using group B's volume to recover the slider position
+func (c *crossfadeMapping) position(gainB float32) float32 {
+	if c.law == crossfadeLawLinear {
+		return gainB
+	}
+
+	return float32(math.Asin(math.Min(float64(gainB), 1)) * 2 / math.Pi)
+}
+
 func (m *sliderMap) iterate(f func(int, []string)) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -76,6 +367,43 @@ func (m *sliderMap) set(key int, value []string) {
 	m.m[key] = value
 }
 
+func (m *sliderMap) getOptions(key int) (*sliderOptions, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	value, ok := m.options[key]
+	return value, ok
+}
+
+func (m *sliderMap) setOptions(key int, value *sliderOptions) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.options[key] = value
+}
+
+// withOverrides returns a new slider map where the other map's sliders replace this map's
+func (m *sliderMap) withOverrides(other *sliderMap) *sliderMap {
+	resultMap := newSliderMap()
+
+	for _, source := range []*sliderMap{m, other} {
+		source.lock.Lock()
+
+		for sliderIdx, targets := range source.m {
+			resultMap.m[sliderIdx] = targets
+			delete(resultMap.options, sliderIdx)
+
+			if options, ok := source.options[sliderIdx]; ok {
+				resultMap.options[sliderIdx] = options
+			}
+		}
+
+		source.lock.Unlock()
+	}
+
+	return resultMap
+}
+
 func (m *sliderMap) String() string {
 	m.lock.Lock()
 	defer m.lock.Unlock()
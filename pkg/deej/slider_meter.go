@@ -0,0 +1,317 @@
+package deej
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SliderLevel is how loud a slider's targets currently are, for VU meters. both levels are between 0 and 1
+type SliderLevel struct {
+	SliderID int
+	Peak     float32
+	RMS      float32
+}
+
+// levelReading is a single session's output level over the last metering period
+type levelReading struct {
+	peak float32
+	rms  float32
+}
+
+// levelMeter measures the output level of a set of sessions, and is implemented per platform (see newLevelMeter)
+type levelMeter interface {
+	// watch replaces the sessions being measured
+	watch(sessions []Session)
+
+	// read returns the level of each watched session since the last read
+	read() map[Session]levelReading
+
+	release() error
+}
+
+// sliderMeter publishes the level of each mapped slider to its subscribers. it only measures anything
+// while there's at least one subscriber, so that it costs nothing otherwise
+type sliderMeter struct {
+	deej   *Deej
+	logger *zap.SugaredLogger
+
+	consumers     []chan []SliderLevel
+	consumersLock sync.Locker
+
+	// set while metering is running, and closed to stop it
+	stopChannel chan bool
+
+	// creates the platform's level meter, on the metering goroutine
+	newLevelMeter func(logger *zap.SugaredLogger, rate int) (levelMeter, error)
+}
+
+// levelAccumulator collects samples (or peaks) between reads. it's safe to add to it from another goroutine
+type levelAccumulator struct {
+	lock       sync.Locker
+	peak       float32
+	sumSquares float64
+	count      int
+}
+
+const (
+	// how many times per second slider levels are published, by default
+	defaultMeteringRate = 20
+	maxMeteringRate     = 60
+
+	// mapped sessions are looked up again this often while metering, rather than every frame
+	meterSessionRefreshInterval = time.Second
+)
+
+func newSliderMeter(deej *Deej, logger *zap.SugaredLogger) *sliderMeter {
+	return &sliderMeter{
+		deej:          deej,
+		logger:        logger.Named("meter"),
+		consumersLock: &sync.Mutex{},
+		newLevelMeter: newLevelMeter,
+	}
+}
+
+// SubscribeToSliderLevels returns a channel that receives the level of every mapped slider, a few times per second.
+// frames are dropped if the subscriber isn't ready to receive them. metering starts with the first subscriber
+func (sm *sliderMeter) SubscribeToSliderLevels() chan []SliderLevel {
+	sm.consumersLock.Lock()
+	defer sm.consumersLock.Unlock()
+
+	ch := make(chan []SliderLevel)
+	sm.consumers = append(sm.consumers, ch)
+
+	if sm.stopChannel == nil {
+		sm.stopChannel = make(chan bool)
+		go sm.run(sm.stopChannel)
+	}
+
+	return ch
+}
+
+// UnsubscribeFromSliderLevels stops sending levels to the given channel. metering stops with the last subscriber
+func (sm *sliderMeter) UnsubscribeFromSliderLevels(ch chan []SliderLevel) {
+	sm.consumersLock.Lock()
+	defer sm.consumersLock.Unlock()
+
+	for idx, consumer := range sm.consumers {
+		if consumer == ch {
+			sm.consumers = append(sm.consumers[:idx], sm.consumers[idx+1:]...)
+			break
+		}
+	}
+
+	if len(sm.consumers) == 0 {
+		sm.stop()
+	}
+}
+
+// stop assumes the consumers lock is held
+func (sm *sliderMeter) stop() {
+	if sm.stopChannel != nil {
+		close(sm.stopChannel)
+		sm.stopChannel = nil
+	}
+}
+
+// release stops metering regardless of subscribers, for when deej is shutting down
+func (sm *sliderMeter) release() {
+	sm.consumersLock.Lock()
+	defer sm.consumersLock.Unlock()
+
+	sm.stop()
+}
+
+func (sm *sliderMeter) run(stopChannel chan bool) {
+	rate := sm.deej.config.currentMeteringRate()
+
+	meter, err := sm.newLevelMeter(sm.logger, rate)
+	if err != nil {
+		sm.logger.Warnw("Failed to create level meter", "error", err)
+
+		// let the next subscriber try again
+		sm.consumersLock.Lock()
+		if sm.stopChannel == stopChannel {
+			sm.stop()
+		}
+		sm.consumersLock.Unlock()
+
+		return
+	}
+
+	defer meter.release()
+
+	sm.logger.Debugw("Metering started", "rate", rate)
+
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	defer ticker.Stop()
+
+	var (
+		sliderSessions map[int][]Session
+		lastRefresh    time.Time
+	)
+
+	for {
+		select {
+		case <-stopChannel:
+			sm.logger.Debug("Metering stopped")
+			return
+
+		case now := <-ticker.C:
+			if now.Sub(lastRefresh) >= meterSessionRefreshInterval {
+				lastRefresh = now
+
+				sliderSessions = sm.deej.sessions.sliderSessions()
+				meter.watch(uniqueSessions(sliderSessions))
+
+				// pick up rate changes from config reloads, too
+				if newRate := sm.deej.config.currentMeteringRate(); newRate != rate {
+					rate = newRate
+					ticker.Reset(time.Second / time.Duration(rate))
+				}
+			}
+
+			sm.publish(sliderLevels(sliderSessions, meter.read()))
+		}
+	}
+}
+
+func (sm *sliderMeter) publish(levels []SliderLevel) {
+	sm.consumersLock.Lock()
+	defer sm.consumersLock.Unlock()
+
+	for _, consumer := range sm.consumers {
+
+		// a meter frame is stale by the time the next one comes, so there's no point in waiting for slow subscribers
+		select {
+		case consumer <- levels:
+		default:
+		}
+	}
+}
+
+// sliderSessions returns the sessions each mapped slider currently controls
+func (m *sessionMap) sliderSessions() map[int][]Session {
+	type sliderTargets struct {
+		options *sliderOptions
+		targets []string
+	}
+
+	sliders := make(map[int]sliderTargets)
+	sliderMapping := m.deej.config.SliderMapping()
+
+	sliderMapping.iterate(func(sliderIdx int, targets []string) {
+		sliders[sliderIdx] = sliderTargets{sliderMapping.options[sliderIdx], targets}
+	})
+
+	result := make(map[int][]Session)
+
+	for sliderIdx, slider := range sliders {
+		targets := slider.targets
+
+		// fallback sliders only control (and so only measure) their first available target
+		if slider.options != nil && slider.options.fallback {
+			target, ok := m.firstAvailableTarget(slider.options, targets)
+			if !ok {
+				continue
+			}
+
+			targets = []string{target}
+		}
+
+		for _, target := range targets {
+			result[sliderIdx] = append(result[sliderIdx], m.sliderTargetSessions(slider.options, target)...)
+		}
+	}
+
+	return result
+}
+
+// uniqueSessions flattens the sessions of all sliders, since several sliders may share a session
+func uniqueSessions(sliderSessions map[int][]Session) []Session {
+	seen := make(map[Session]bool)
+	result := []Session{}
+
+	for _, sessions := range sliderSessions {
+		for _, session := range sessions {
+			if !seen[session] {
+				seen[session] = true
+				result = append(result, session)
+			}
+		}
+	}
+
+	return result
+}
+
+// sliderLevels combines the readings of each slider's sessions: the loudest peak, and the sum of their power
+func sliderLevels(sliderSessions map[int][]Session, readings map[Session]levelReading) []SliderLevel {
+	levels := []SliderLevel{}
+
+	for sliderIdx, sessions := range sliderSessions {
+		level := SliderLevel{SliderID: sliderIdx}
+		power := 0.0
+
+		for _, session := range sessions {
+			reading, ok := readings[session]
+			if !ok {
+				continue
+			}
+
+			if reading.peak > level.Peak {
+				level.Peak = reading.peak
+			}
+
+			power += float64(reading.rms) * float64(reading.rms)
+		}
+
+		level.RMS = float32(math.Min(math.Sqrt(power), 1))
+		levels = append(levels, level)
+	}
+
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i].SliderID < levels[j].SliderID
+	})
+
+	return levels
+}
+
+func newLevelAccumulator() *levelAccumulator {
+	return &levelAccumulator{lock: &sync.Mutex{}}
+}
+
+func (a *levelAccumulator) add(samples []float32) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	for _, sample := range samples {
+		sample = float32(math.Abs(float64(sample)))
+
+		if sample > a.peak {
+			a.peak = sample
+		}
+
+		a.sumSquares += float64(sample) * float64(sample)
+		a.count++
+	}
+}
+
+// take returns what was accumulated since the last call, and starts over
+func (a *levelAccumulator) take() levelReading {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	reading := levelReading{peak: a.peak}
+	if a.count > 0 {
+		reading.rms = float32(math.Sqrt(a.sumSquares / float64(a.count)))
+	}
+
+	a.peak = 0
+	a.sumSquares = 0
+	a.count = 0
+
+	return reading
+}
@@ -0,0 +1,280 @@
+package deej
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// fakeSession is just enough of a session for the session map and the meter
+type fakeSession struct {
+	key string
+}
+
+func (s *fakeSession) GetVolume() float32        { return 1 }
+func (s *fakeSession) SetVolume(v float32) error { return nil }
+func (s *fakeSession) GetMute() bool             { return false }
+func (s *fakeSession) SetMute(m bool) error      { return nil }
+func (s *fakeSession) Key() string               { return s.key }
+func (s *fakeSession) Release()                  {}
+
+// fakeLevelMeter reports when it's released, and otherwise measures silence
+type fakeLevelMeter struct {
+	released chan bool
+}
+
+func (m *fakeLevelMeter) watch(sessions []Session) {}
+
+func (m *fakeLevelMeter) read() map[Session]levelReading {
+	return map[Session]levelReading{}
+}
+
+func (m *fakeLevelMeter) release() error {
+	close(m.released)
+	return nil
+}
+
+func floatsNear(a float32, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-6
+}
+
+func TestLevelAccumulator(t *testing.T) {
+	a := newLevelAccumulator()
+
+	if reading := a.take(); reading.peak != 0 || reading.rms != 0 {
+		t.Fatalf("expected silence without samples, got %+v", reading)
+	}
+
+	a.add([]float32{0.5, -0.5})
+	a.add([]float32{-0.8, 0})
+
+	reading := a.take()
+
+	if !floatsNear(reading.peak, 0.8) {
+		t.Fatalf("expected the loudest sample as the peak regardless of its sign, got %v", reading.peak)
+	}
+
+	expectedRMS := float32(math.Sqrt((0.25 + 0.25 + 0.64 + 0) / 4))
+	if !floatsNear(reading.rms, expectedRMS) {
+		t.Fatalf("expected rms %v, got %v", expectedRMS, reading.rms)
+	}
+
+	// taking starts over
+	if reading := a.take(); reading.peak != 0 || reading.rms != 0 {
+		t.Fatalf("expected silence after taking, got %+v", reading)
+	}
+
+	a.add([]float32{0.1})
+	if reading := a.take(); !floatsNear(reading.peak, 0.1) || !floatsNear(reading.rms, 0.1) {
+		t.Fatalf("expected only the samples since the last take, got %+v", reading)
+	}
+}
+
+func TestSliderLevels(t *testing.T) {
+	first := &fakeSession{key: "first"}
+	second := &fakeSession{key: "second"}
+	loud := &fakeSession{key: "loud"}
+	silent := &fakeSession{key: "silent"}
+
+	sliderSessions := map[int][]Session{
+		2: {first, second},
+		0: {loud, first},
+		1: {silent},
+		3: {},
+	}
+
+	readings := map[Session]levelReading{
+		first:  {peak: 0.3, rms: 0.3},
+		second: {peak: 0.5, rms: 0.4},
+		loud:   {peak: 1, rms: 0.98},
+	}
+
+	levels := sliderLevels(sliderSessions, readings)
+
+	if len(levels) != 4 {
+		t.Fatalf("expected a level for every slider, got %+v", levels)
+	}
+
+	for idx, level := range levels {
+		if level.SliderID != idx {
+			t.Fatalf("expected levels sorted by slider, got %+v", levels)
+		}
+	}
+
+	// the loudest peak, and the power of both sessions together
+	if !floatsNear(levels[2].Peak, 0.5) || !floatsNear(levels[2].RMS, 0.5) {
+		t.Fatalf("expected peak 0.5 and rms 0.5 for shared sessions, got %+v", levels[2])
+	}
+
+	// added power can't go past full scale
+	if !floatsNear(levels[0].Peak, 1) || !floatsNear(levels[0].RMS, 1) {
+		t.Fatalf("expected the rms to be clamped to 1, got %+v", levels[0])
+	}
+
+	// sessions without a reading, and sliders without sessions, are silent
+	if levels[1].Peak != 0 || levels[1].RMS != 0 || levels[3].Peak != 0 || levels[3].RMS != 0 {
+		t.Fatalf("expected silence without readings, got %+v", levels)
+	}
+}
+
+// newTestSliderMeter returns a meter over an empty slider mapping, whose level meters are reported on the
+// returned channel as they're created. failures fail the next that many level meter creations
+func newTestSliderMeter(t *testing.T, failures int) (*sliderMeter, chan *fakeLevelMeter) {
+	logger := zap.NewNop().Sugar()
+
+	deej := &Deej{
+		logger: logger,
+		config: &CanonicalConfig{
+			sliderMapping: newSliderMap(),
+			mappingLock:   &sync.Mutex{},
+			MeteringRate:  maxMeteringRate,
+		},
+	}
+
+	sessions, err := newSessionMap(deej, logger, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deej.sessions = sessions
+
+	meters := make(chan *fakeLevelMeter, 1)
+	failuresLock := &sync.Mutex{}
+
+	sm := newSliderMeter(deej, logger)
+	sm.newLevelMeter = func(logger *zap.SugaredLogger, rate int) (levelMeter, error) {
+		failuresLock.Lock()
+		defer failuresLock.Unlock()
+
+		if failures > 0 {
+			failures--
+			meters <- nil
+			return nil, errors.New("no meter")
+		}
+
+		meter := &fakeLevelMeter{released: make(chan bool)}
+		meters <- meter
+
+		return meter, nil
+	}
+
+	return sm, meters
+}
+
+func receiveLevelMeter(t *testing.T, meters <-chan *fakeLevelMeter) *fakeLevelMeter {
+	select {
+	case meter := <-meters:
+		return meter
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for metering to start")
+	}
+
+	return nil
+}
+
+func receiveSliderLevels(t *testing.T, ch <-chan []SliderLevel) {
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for slider levels")
+	}
+}
+
+func waitForRelease(t *testing.T, meter *fakeLevelMeter) {
+	select {
+	case <-meter.released:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the level meter to be released")
+	}
+}
+
+func expectNoNewLevelMeter(t *testing.T, meters <-chan *fakeLevelMeter) {
+	select {
+	case <-meters:
+		t.Fatal("expected metering to keep running on the same level meter")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSliderMeterStartsAndStopsWithSubscribers(t *testing.T) {
+	sm, meters := newTestSliderMeter(t, 0)
+	defer sm.release()
+
+	first := sm.SubscribeToSliderLevels()
+	meter := receiveLevelMeter(t, meters)
+	receiveSliderLevels(t, first)
+
+	// a second subscriber shares the running meter
+	second := sm.SubscribeToSliderLevels()
+	receiveSliderLevels(t, second)
+	expectNoNewLevelMeter(t, meters)
+
+	// and metering keeps running until the last one leaves
+	sm.UnsubscribeFromSliderLevels(first)
+	receiveSliderLevels(t, second)
+
+	select {
+	case <-meter.released:
+		t.Fatal("expected metering to keep running while subscribed")
+	default:
+	}
+
+	sm.UnsubscribeFromSliderLevels(second)
+	waitForRelease(t, meter)
+
+	// subscribing again starts over with a new meter
+	third := sm.SubscribeToSliderLevels()
+	newMeter := receiveLevelMeter(t, meters)
+	receiveSliderLevels(t, third)
+
+	if newMeter == meter {
+		t.Fatal("expected a new level meter after restarting")
+	}
+
+	sm.UnsubscribeFromSliderLevels(third)
+	waitForRelease(t, newMeter)
+}
+
+func TestSliderMeterRetriesAfterFailure(t *testing.T) {
+	sm, meters := newTestSliderMeter(t, 1)
+	defer sm.release()
+
+	first := sm.SubscribeToSliderLevels()
+	if meter := receiveLevelMeter(t, meters); meter != nil {
+		t.Fatal("expected the first level meter to fail")
+	}
+
+	// metering gives up by itself, so wait for that before the next subscriber comes along
+	deadline := time.Now().Add(time.Second)
+	for {
+		sm.consumersLock.Lock()
+		stopped := sm.stopChannel == nil
+		sm.consumersLock.Unlock()
+
+		if stopped {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for metering to give up")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	second := sm.SubscribeToSliderLevels()
+	meter := receiveLevelMeter(t, meters)
+	if meter == nil {
+		t.Fatal("expected the next subscriber to start metering again")
+	}
+
+	receiveSliderLevels(t, second)
+
+	sm.UnsubscribeFromSliderLevels(first)
+	sm.UnsubscribeFromSliderLevels(second)
+	waitForRelease(t, meter)
+}
@@ -0,0 +1,162 @@
+package deej
+
+import (
+	"sync"
+
+	"github.com/thoas/go-funk"
+)
+
+// soloState mutes everything except a few chosen targets (e.g. the call app during a meeting),
+// and remembers what was muted beforehand so that ending the solo restores it exactly
+type soloState struct {
+	active bool
+
+	// each affected session's mute state from before it was muted by the solo. this is kept per session
+	// rather than per key, since instances of the same app (i.e. browser tabs) can each be muted differently
+	previousMutes map[Session]bool
+
+	lock sync.Locker
+}
+
+func newSoloState() *soloState {
+	return &soloState{
+		previousMutes: make(map[Session]bool),
+		lock:          &sync.Mutex{},
+	}
+}
+
+func (m *sessionMap) soloActive() bool {
+	m.solo.lock.Lock()
+	defer m.solo.lock.Unlock()
+
+	return m.solo.active
+}
+
+func (m *sessionMap) toggleSolo() {
+	if m.soloActive() {
+		m.unsoloSessions()
+	} else {
+		m.soloSessions()
+	}
+}
+
+// soloSessions mutes every affected session, keeping only the configured solo targets audible
+func (m *sessionMap) soloSessions() {
+	m.solo.lock.Lock()
+	defer m.solo.lock.Unlock()
+
+	if m.solo.active {
+		return
+	}
+
+	m.solo.active = true
+	m.solo.previousMutes = make(map[Session]bool)
+
+	m.logger.Infow("Solo started, muting everything else", "soloTargets", m.deej.config.Solo.Targets)
+
+	for _, session := range m.allSessions() {
+		m.muteForSolo(session)
+	}
+}
+
+// unsoloSessions puts back the mute state each affected session had before the solo started
+func (m *sessionMap) unsoloSessions() {
+	m.solo.lock.Lock()
+	defer m.solo.lock.Unlock()
+
+	if !m.solo.active {
+		return
+	}
+
+	m.solo.active = false
+
+	m.logger.Infow("Solo ended, restoring previous mute states", "sessions", len(m.solo.previousMutes))
+
+	for session, previousMute := range m.solo.previousMutes {
+
+		// sessions that went away during the solo have been released, and there's nothing to restore
+		if !m.contains(session) {
+			continue
+		}
+
+		if err := session.SetMute(previousMute); err != nil {
+			m.logger.Warnw("Failed to restore session mute after solo", "session", session.Key(), "error", err)
+		}
+	}
+
+	m.solo.previousMutes = make(map[Session]bool)
+}
+
+// applySoloToNewSession makes sure sessions that appear while a solo is active are muted too
+func (m *sessionMap) applySoloToNewSession(session Session) {
+	m.solo.lock.Lock()
+	defer m.solo.lock.Unlock()
+
+	if m.solo.active {
+		m.muteForSolo(session)
+	}
+}
+
+// muteForSolo mutes a single session if the solo affects it, remembering its previous mute state.
+// the solo lock must be held by the caller
+func (m *sessionMap) muteForSolo(session Session) {
+	if !m.soloAffects(session) {
+		return
+	}
+
+	if _, seen := m.solo.previousMutes[session]; !seen {
+		m.solo.previousMutes[session] = m.previousSoloMute(session)
+	}
+
+	if err := session.SetMute(true); err != nil {
+		m.logger.Warnw("Failed to mute session for solo", "session", session.Key(), "error", err)
+	}
+}
+
+// previousSoloMute returns the mute state to restore for a session the solo hasn't seen yet.
+// refreshing the session map replaces every session with a new instance, which the solo has already muted -
+// so if an instance with the same key has gone away since, the new one takes over its remembered state instead.
+// the solo lock must be held by the caller
+func (m *sessionMap) previousSoloMute(session Session) bool {
+	for previousSession, previousMute := range m.solo.previousMutes {
+		if previousSession.Key() == session.Key() && !m.contains(previousSession) {
+			delete(m.solo.previousMutes, previousSession)
+			return previousMute
+		}
+	}
+
+	return session.GetMute()
+}
+
+// soloAffects returns true if the given session should be muted by a solo
+func (m *sessionMap) soloAffects(session Session) bool {
+	key := session.Key()
+
+	// muting whole devices would also mute the solo targets, so these are never affected
+	if funk.ContainsString([]string{masterSessionName, inputSessionName}, key) ||
+		m.isDeviceSession(session) {
+		return false
+	}
+
+	for _, target := range m.deej.config.Solo.Targets {
+		for _, soloSession := range m.targetSessions(target) {
+			if soloSession.Key() == key {
+				return false
+			}
+		}
+	}
+
+	return m.deej.config.Solo.IncludeUnmapped || m.sessionMapped(session)
+}
+
+// handleSoloButton intercepts presses of the configured solo button, toggling the solo instead of muting
+func (m *sessionMap) handleSoloButton(event SliderEvent) SliderEvent {
+	if !event.ToggleMute || event.SliderID != m.deej.config.Solo.Button {
+		return event
+	}
+
+	m.toggleSolo()
+
+	event.ToggleMute = false
+	return event
+}
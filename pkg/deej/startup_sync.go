@@ -0,0 +1,162 @@
+package deej
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/omriharel/deej/pkg/deej/util"
+)
+
+// startupSyncState decides what happens with each slider's first value after connecting or reloading
+// the config, when the faders and the OS volumes are likely to disagree. sliders that shouldn't be applied
+// right away are held back here until the user picks them up or moves them
+type startupSyncState struct {
+	pending map[int]*pendingSlider
+	lock    sync.Locker
+}
+
+type pendingSlider struct {
+	policy string
+
+	// the slider's value when it was held back
+	initialValue float32
+
+	// for pickup: the volume the OS had, and which side of it the slider started on
+	osValue float32
+	above   bool
+}
+
+const (
+	// the fader's position is applied right away, overwriting the OS volume (deej's classic behavior)
+	startupSyncApply = "apply"
+
+	// the OS volume is kept until the fader is moved to (or past) it, like a "soft takeover" on mixers
+	startupSyncAdopt = "adopt"
+
+	// the OS volume is kept until the fader is moved at all
+	startupSyncMove = "move"
+
+	// how close the fader has to be to the OS volume to count as picked up without passing it
+	startupSyncPickupTolerance = 0.02
+)
+
+func newStartupSyncState() *startupSyncState {
+	return &startupSyncState{
+		pending: make(map[int]*pendingSlider),
+		lock:    &sync.Mutex{},
+	}
+}
+
+// startupSyncPolicy returns the slider's own startup policy, or the configured default if it doesn't have one
+func (m *sessionMap) startupSyncPolicy(sliderIdx int) string {
+	if options, ok := m.deej.config.SliderMapping().getOptions(sliderIdx); ok && options != nil && options.startup != "" {
+		return options.startup
+	}
+
+	return m.deej.config.StartupSync
+}
+
+// filterStartupEvent returns true if the given slider event's value should be discarded because its
+// slider hasn't been picked up or moved since deej connected or reloaded its config. only the value is
+// held back, so the second value is true if the event's mute toggle should still apply
+func (m *sessionMap) filterStartupEvent(event SliderEvent) (bool, bool) {
+	m.startupSync.lock.Lock()
+	defer m.startupSync.lock.Unlock()
+
+	if event.Initial {
+		if m.holdBackInitialEvent(event) {
+			return true, event.ToggleMute
+		}
+
+		return false, false
+	}
+
+	pending, ok := m.startupSync.pending[event.SliderID]
+	if !ok {
+		return false, false
+	}
+
+	value := event.PercentValue
+
+	switch pending.policy {
+	case startupSyncMove:
+		// noise shouldn't count as the slider being moved
+		if !util.SignificantlyDifferent(pending.initialValue, value, m.deej.config.NoiseReductionLevel) {
+			return true, event.ToggleMute
+		}
+
+	case startupSyncAdopt:
+		reached := value >= pending.osValue-startupSyncPickupTolerance &&
+			value <= pending.osValue+startupSyncPickupTolerance
+
+		passed := (pending.above && value <= pending.osValue) || (!pending.above && value >= pending.osValue)
+
+		if !reached && !passed {
+			return true, event.ToggleMute
+		}
+	}
+
+	m.logger.Debugw("Slider engaged after startup", "sliderIdx", event.SliderID, "policy", pending.policy)
+	delete(m.startupSync.pending, event.SliderID)
+
+	return false, false
+}
+
+// holdBackInitialEvent applies the slider's startup policy to its first event, returning true if it's held back.
+// the startup sync lock must be held by the caller
+func (m *sessionMap) holdBackInitialEvent(event SliderEvent) bool {
+
+	// a new first value starts the slider over, whatever was pending for it before
+	delete(m.startupSync.pending, event.SliderID)
+
+	// unmapped sliders don't have any volume to keep
+	if _, ok := m.deej.config.SliderMapping().get(event.SliderID); !ok {
+		return false
+	}
+
+	policy := m.startupSyncPolicy(event.SliderID)
+
+	switch policy {
+	case startupSyncMove:
+		m.startupSync.pending[event.SliderID] = &pendingSlider{
+			policy:       policy,
+			initialValue: event.PercentValue,
+		}
+
+	case startupSyncAdopt:
+		osValue := m.getCurrentVolume(event.SliderID)
+
+		// nothing to adopt (yet), or the fader is already where the OS is
+		if osValue < 0 ||
+			(event.PercentValue >= osValue-startupSyncPickupTolerance &&
+				event.PercentValue <= osValue+startupSyncPickupTolerance) {
+			return false
+		}
+
+		m.startupSync.pending[event.SliderID] = &pendingSlider{
+			policy:       policy,
+			initialValue: event.PercentValue,
+			osValue:      osValue,
+			above:        event.PercentValue > osValue,
+		}
+
+		// anything that follows this slider (e.g. relative sliders following master) should follow the OS volume too
+		m.setSliderValue(event.SliderID, osValue)
+
+	default:
+		return false
+	}
+
+	m.logger.Debugw("Holding back slider until it's engaged",
+		"sliderIdx", event.SliderID,
+		"policy", policy,
+		"value", event.PercentValue)
+
+	return true
+}
+
+// parseStartupSyncPolicy lowercases a configured policy, returning false if it isn't a known one
+func parseStartupSyncPolicy(value string) (string, bool) {
+	policy := strings.ToLower(value)
+	return policy, policy == startupSyncApply || policy == startupSyncAdopt || policy == startupSyncMove
+}
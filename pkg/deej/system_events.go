@@ -0,0 +1,191 @@
+package deej
+
+import (
+	"time"
+
+	"github.com/omriharel/deej/pkg/deej/util"
+)
+
+// SystemEvent is a change in the desktop session or power state that deej reacts to
+type SystemEvent int
+
+const (
+	// SystemEventLock is sent when the desktop session is locked
+	SystemEventLock SystemEvent = iota
+
+	// SystemEventUnlock is sent when the desktop session is unlocked
+	SystemEventUnlock
+
+	// SystemEventSleep is sent right before the system suspends or hibernates
+	SystemEventSleep
+
+	// SystemEventResume is sent once the system is back from suspend or hibernation
+	SystemEventResume
+)
+
+// SystemEventWatcher represents an entity that reports session lock and sleep events
+type SystemEventWatcher interface {
+	Events() <-chan SystemEvent
+
+	// EventHandled is called once deej is done reacting to an event, i.e. so that sleep can go ahead
+	EventHandled(event SystemEvent)
+
+	Release() error
+}
+
+// systemEventActions describe what to do with the audio when the session locks or the system goes to sleep
+type systemEventActions struct {
+	Mute   []string
+	Levels map[string]float32
+	Pause  bool
+
+	// whether to put back what was muted or changed once the session unlocks (or the system resumes)
+	Restore bool
+}
+
+// systemEventState remembers what a lock or sleep changed, so that it can be restored afterwards
+type systemEventState struct {
+	// previous mute states and volumes, keyed by session key
+	previousMutes   map[string]bool
+	previousVolumes map[string]float32
+}
+
+const (
+	// how long to wait between attempts to reconnect the serial connection after a resume,
+	// since usb devices usually take a moment to come back
+	resumeReconnectInterval = time.Second
+	resumeReconnectAttempts = 10
+)
+
+func (e SystemEvent) String() string {
+	switch e {
+	case SystemEventLock:
+		return "lock"
+	case SystemEventUnlock:
+		return "unlock"
+	case SystemEventSleep:
+		return "sleep"
+	case SystemEventResume:
+		return "resume"
+	default:
+		return "unknown"
+	}
+}
+
+func (d *Deej) watchSystemEvents() {
+	logger := d.logger.Named("system_events")
+
+	watcher, err := newSystemEventWatcher(logger)
+	if err != nil {
+		logger.Warnw("Failed to watch for session lock and sleep events", "error", err)
+		return
+	}
+
+	defer watcher.Release()
+
+	var (
+		lockState  *systemEventState
+		sleepState *systemEventState
+	)
+
+	for event := range watcher.Events() {
+		logger.Infow("Received system event", "event", event)
+
+		switch event {
+		case SystemEventLock:
+			lockState = d.sessions.applySystemEventActions(d.config.SystemEvents.Lock)
+
+		case SystemEventUnlock:
+			if lockState != nil && d.config.SystemEvents.Lock.Restore {
+				d.sessions.restoreSystemEventState(lockState)
+			}
+
+			lockState = nil
+
+		case SystemEventSleep:
+			sleepState = d.sessions.applySystemEventActions(d.config.SystemEvents.Sleep)
+
+		case SystemEventResume:
+
+			// sessions (and their keys) may have come and gone while asleep, so find them again before restoring
+			d.sessions.refreshSessions(true)
+
+			if sleepState != nil && d.config.SystemEvents.Sleep.Restore {
+				d.sessions.restoreSystemEventState(sleepState)
+			}
+
+			sleepState = nil
+
+			if d.config.SystemEvents.ReconnectOnResume {
+				go d.serial.reconnect(resumeReconnectAttempts, resumeReconnectInterval)
+			}
+		}
+
+		watcher.EventHandled(event)
+	}
+}
+
+// applySystemEventActions mutes and sets volumes as configured, and returns what they were before
+func (m *sessionMap) applySystemEventActions(actions systemEventActions) *systemEventState {
+	state := &systemEventState{
+		previousMutes:   make(map[string]bool),
+		previousVolumes: make(map[string]float32),
+	}
+
+	for _, target := range actions.Mute {
+		for _, session := range m.targetSessions(target) {
+			if _, seen := state.previousMutes[session.Key()]; !seen {
+				state.previousMutes[session.Key()] = session.GetMute()
+			}
+
+			if err := session.SetMute(true); err != nil {
+				m.logger.Warnw("Failed to mute session for system event", "target", target, "error", err)
+			}
+		}
+	}
+
+	for target, level := range actions.Levels {
+		for _, session := range m.targetSessions(target) {
+			if _, seen := state.previousVolumes[session.Key()]; !seen {
+				state.previousVolumes[session.Key()] = session.GetVolume()
+			}
+
+			if err := session.SetVolume(level); err != nil {
+				m.logger.Warnw("Failed to set session volume for system event", "target", target, "error", err)
+			}
+		}
+	}
+
+	if actions.Pause {
+		if err := util.PauseMediaPlayers(); err != nil {
+			m.logger.Warnw("Failed to pause media players", "error", err)
+		}
+	}
+
+	return state
+}
+
+// restoreSystemEventState puts back the mute states and volumes that were changed by a lock or sleep
+func (m *sessionMap) restoreSystemEventState(state *systemEventState) {
+	m.logger.Debugw("Restoring sessions after system event",
+		"mutes", len(state.previousMutes),
+		"volumes", len(state.previousVolumes))
+
+	for key, previousMute := range state.previousMutes {
+		sessions, _ := m.get(key)
+		for _, session := range sessions {
+			if err := session.SetMute(previousMute); err != nil {
+				m.logger.Warnw("Failed to restore session mute", "session", key, "error", err)
+			}
+		}
+	}
+
+	for key, previousVolume := range state.previousVolumes {
+		sessions, _ := m.get(key)
+		for _, session := range sessions {
+			if err := session.SetVolume(previousVolume); err != nil {
+				m.logger.Warnw("Failed to restore session volume", "session", key, "error", err)
+			}
+		}
+	}
+}
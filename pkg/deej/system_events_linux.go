@@ -0,0 +1,212 @@
+package deej
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+
+	"github.com/godbus/dbus"
+	"go.uber.org/zap"
+)
+
+// logindBus is the part of a system bus connection that the logind watcher uses, so that a fake bus can stand in for it
+type logindBus interface {
+	BusObject() dbus.BusObject
+	Object(dest string, path dbus.ObjectPath) dbus.BusObject
+	Signal(ch chan<- *dbus.Signal)
+	Close() error
+}
+
+type logindWatcher struct {
+	logger *zap.SugaredLogger
+
+	bus     logindBus
+	signals chan *dbus.Signal
+	events  chan SystemEvent
+
+	// a delay inhibitor lock that holds off sleep until the sleep actions are done, see EventHandled
+	sleepLock     dbus.UnixFD
+	haveSleepLock bool
+	sleepLockLock sync.Locker
+}
+
+const (
+	logindDestination      = "org.freedesktop.login1"
+	logindPath             = dbus.ObjectPath("/org/freedesktop/login1")
+	logindManagerInterface = "org.freedesktop.login1.Manager"
+	logindSessionInterface = "org.freedesktop.login1.Session"
+
+	// "auto" is this process's session, or the user's graphical session if we're not running in one
+	logindAutoSession = "auto"
+
+	logindInhibitWhat      = "sleep"
+	logindInhibitWho       = "deej"
+	logindInhibitWhy       = "Applying sleep actions to audio sessions"
+	logindInhibitModeDelay = "delay"
+)
+
+func newSystemEventWatcher(logger *zap.SugaredLogger) (SystemEventWatcher, error) {
+	bus, err := dbus.SystemBusPrivate()
+	if err != nil {
+		return nil, fmt.Errorf("connect to system bus: %w", err)
+	}
+
+	// private connections need to authenticate and say hello by themselves
+	if err := bus.Auth(nil); err != nil {
+		bus.Close()
+		return nil, fmt.Errorf("authenticate with system bus: %w", err)
+	}
+
+	if err := bus.Hello(); err != nil {
+		bus.Close()
+		return nil, fmt.Errorf("say hello to system bus: %w", err)
+	}
+
+	return newLogindWatcher(logger, bus)
+}
+
+func newLogindWatcher(logger *zap.SugaredLogger, bus logindBus) (*logindWatcher, error) {
+	w := &logindWatcher{
+		logger:  logger.Named("logind"),
+		bus:     bus,
+		signals: make(chan *dbus.Signal, 10),
+		events:  make(chan SystemEvent),
+
+		sleepLockLock: &sync.Mutex{},
+	}
+
+	matchRules := []string{
+		fmt.Sprintf("type='signal',interface='%s',member='PrepareForSleep',path='%s'",
+			logindManagerInterface, logindPath),
+	}
+
+	// lock and unlock are sent to a specific session, so find ours - or listen to all of them if we can't
+	var sessionPath dbus.ObjectPath
+	err := bus.Object(logindDestination, logindPath).
+		Call(logindManagerInterface+".GetSession", 0, logindAutoSession).
+		Store(&sessionPath)
+
+	if err != nil {
+		w.logger.Warnw("Failed to find our logind session, listening for locks of any session", "error", err)
+		matchRules = append(matchRules, fmt.Sprintf("type='signal',interface='%s'", logindSessionInterface))
+	} else {
+		w.logger.Debugw("Found logind session", "path", sessionPath)
+		matchRules = append(matchRules, fmt.Sprintf("type='signal',interface='%s',path='%s'",
+			logindSessionInterface, sessionPath))
+	}
+
+	for _, rule := range matchRules {
+		if call := bus.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, rule); call.Err != nil {
+			return nil, fmt.Errorf("add signal match rule %s: %w", rule, call.Err)
+		}
+	}
+
+	w.takeSleepLock()
+
+	bus.Signal(w.signals)
+	go w.translateSignals()
+
+	w.logger.Debug("Created logind watcher instance")
+
+	return w, nil
+}
+
+func (w *logindWatcher) Events() <-chan SystemEvent {
+	return w.events
+}
+
+// EventHandled lets sleep go ahead once its actions are done, by releasing the inhibitor lock
+func (w *logindWatcher) EventHandled(event SystemEvent) {
+	if event == SystemEventSleep {
+		w.releaseSleepLock()
+	}
+}
+
+func (w *logindWatcher) Release() error {
+	w.releaseSleepLock()
+
+	if err := w.bus.Close(); err != nil {
+		w.logger.Warnw("Failed to close system bus connection", "error", err)
+		return fmt.Errorf("close system bus connection: %w", err)
+	}
+
+	return nil
+}
+
+// translateSignals turns logind's signals into system events, until the bus connection goes away
+func (w *logindWatcher) translateSignals() {
+	defer close(w.events)
+
+	for signal := range w.signals {
+		switch signal.Name {
+		case logindSessionInterface + ".Lock":
+			w.events <- SystemEventLock
+
+		case logindSessionInterface + ".Unlock":
+			w.events <- SystemEventUnlock
+
+		// sent with true before going to sleep, and with false after waking up
+		case logindManagerInterface + ".PrepareForSleep":
+			if len(signal.Body) == 0 {
+				continue
+			}
+
+			if sleeping, ok := signal.Body[0].(bool); ok && sleeping {
+				w.events <- SystemEventSleep
+			} else if ok {
+
+				// logind lets go of everyone's delay locks when sleeping, so the next sleep needs a new one
+				w.takeSleepLock()
+				w.events <- SystemEventResume
+			}
+		}
+	}
+
+	w.logger.Debug("System bus signals ended")
+}
+
+// takeSleepLock asks logind to hold off sleeping until we're done preparing for it (or for its maximum delay).
+// without one, sleep actions race the system going to sleep
+func (w *logindWatcher) takeSleepLock() {
+	w.sleepLockLock.Lock()
+	defer w.sleepLockLock.Unlock()
+
+	if w.haveSleepLock {
+		return
+	}
+
+	var fd dbus.UnixFD
+
+	err := w.bus.Object(logindDestination, logindPath).
+		Call(logindManagerInterface+".Inhibit", 0,
+			logindInhibitWhat, logindInhibitWho, logindInhibitWhy, logindInhibitModeDelay).
+		Store(&fd)
+
+	if err != nil {
+		w.logger.Warnw("Failed to take sleep inhibitor lock, sleep actions may not finish in time", "error", err)
+		return
+	}
+
+	w.sleepLock = fd
+	w.haveSleepLock = true
+
+	w.logger.Debug("Took sleep inhibitor lock")
+}
+
+// releaseSleepLock closes the inhibitor lock's file descriptor, which is how logind is told we're ready
+func (w *logindWatcher) releaseSleepLock() {
+	w.sleepLockLock.Lock()
+	defer w.sleepLockLock.Unlock()
+
+	if !w.haveSleepLock {
+		return
+	}
+
+	if err := syscall.Close(int(w.sleepLock)); err != nil {
+		w.logger.Warnw("Failed to release sleep inhibitor lock", "error", err)
+	} else {
+		w.logger.Debug("Released sleep inhibitor lock")
+	}
+
+	w.haveSleepLock = false
+}
@@ -0,0 +1,259 @@
+package deej
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus"
+	"go.uber.org/zap"
+)
+
+// fakeLogindBus answers the few logind calls the watcher makes, and lets tests send it signals
+type fakeLogindBus struct {
+	lock sync.Locker
+
+	sessionPath dbus.ObjectPath
+	sessionErr  error
+
+	matchRules []string
+
+	// each inhibitor lock is the read end of a pipe, so that tests can tell when it's been closed
+	// by writing to the other end (fd numbers get reused, so only the write ends are kept)
+	inhibitorWriteEnds []int
+
+	signals chan<- *dbus.Signal
+}
+
+type fakeBusObject struct {
+	bus  *fakeLogindBus
+	dest string
+	path dbus.ObjectPath
+}
+
+func newFakeLogindBus() *fakeLogindBus {
+	return &fakeLogindBus{
+		lock:        &sync.Mutex{},
+		sessionPath: "/org/freedesktop/login1/session/_31",
+	}
+}
+
+func (b *fakeLogindBus) BusObject() dbus.BusObject {
+	return &fakeBusObject{bus: b, dest: "org.freedesktop.DBus", path: "/org/freedesktop/DBus"}
+}
+
+func (b *fakeLogindBus) Object(dest string, path dbus.ObjectPath) dbus.BusObject {
+	return &fakeBusObject{bus: b, dest: dest, path: path}
+}
+
+func (b *fakeLogindBus) Signal(ch chan<- *dbus.Signal) {
+	b.signals = ch
+}
+
+func (b *fakeLogindBus) Close() error {
+	close(b.signals)
+	return nil
+}
+
+func (b *fakeLogindBus) send(name string, body ...interface{}) {
+	b.signals <- &dbus.Signal{Name: name, Body: body}
+}
+
+// heldInhibitors returns how many of the inhibitor locks handed out are still open
+func (b *fakeLogindBus) heldInhibitors(t *testing.T) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	held := 0
+
+	for _, fd := range b.inhibitorWriteEnds {
+
+		// writing to a pipe whose read end is closed fails
+		if _, err := syscall.Write(fd, []byte{0}); err == nil {
+			held++
+		} else if !errors.Is(err, syscall.EPIPE) {
+			t.Fatalf("unexpected error checking inhibitor lock: %v", err)
+		}
+	}
+
+	return held
+}
+
+func (o *fakeBusObject) Call(method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	call := &dbus.Call{Destination: o.dest, Path: o.path, Method: method, Args: args}
+
+	o.bus.lock.Lock()
+	defer o.bus.lock.Unlock()
+
+	switch method {
+	case "org.freedesktop.DBus.AddMatch":
+		o.bus.matchRules = append(o.bus.matchRules, args[0].(string))
+
+	case logindManagerInterface + ".GetSession":
+		if o.bus.sessionErr != nil {
+			call.Err = o.bus.sessionErr
+		} else {
+			call.Body = []interface{}{o.bus.sessionPath}
+		}
+
+	case logindManagerInterface + ".Inhibit":
+		fds := make([]int, 2)
+		if err := syscall.Pipe(fds); err != nil {
+			call.Err = err
+			break
+		}
+
+		o.bus.inhibitorWriteEnds = append(o.bus.inhibitorWriteEnds, fds[1])
+		call.Body = []interface{}{dbus.UnixFD(fds[0])}
+
+	default:
+		call.Err = errors.New("unknown method " + method)
+	}
+
+	return call
+}
+
+func (o *fakeBusObject) Go(method string, flags dbus.Flags, ch chan *dbus.Call, args ...interface{}) *dbus.Call {
+	call := o.Call(method, flags, args...)
+	if ch != nil {
+		ch <- call
+	}
+
+	return call
+}
+
+func (o *fakeBusObject) GetProperty(p string) (dbus.Variant, error) {
+	return dbus.Variant{}, errors.New("no properties")
+}
+
+func (o *fakeBusObject) Destination() string {
+	return o.dest
+}
+
+func (o *fakeBusObject) Path() dbus.ObjectPath {
+	return o.path
+}
+
+func receiveSystemEvent(t *testing.T, events <-chan SystemEvent) SystemEvent {
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("events channel closed")
+		}
+
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for system event")
+	}
+
+	return 0
+}
+
+func TestLogindWatcherMatchesOwnSession(t *testing.T) {
+	bus := newFakeLogindBus()
+
+	watcher, err := newLogindWatcher(zap.NewNop().Sugar(), bus)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer watcher.Release()
+
+	if len(bus.matchRules) != 2 {
+		t.Fatalf("expected 2 match rules, got %v", bus.matchRules)
+	}
+
+	if !strings.Contains(bus.matchRules[0], "member='PrepareForSleep'") {
+		t.Fatalf("expected a PrepareForSleep match rule, got %s", bus.matchRules[0])
+	}
+
+	if !strings.Contains(bus.matchRules[1], "path='"+string(bus.sessionPath)+"'") {
+		t.Fatalf("expected a match rule for our session, got %s", bus.matchRules[1])
+	}
+}
+
+func TestLogindWatcherMatchesAnySessionWithoutOwn(t *testing.T) {
+	bus := newFakeLogindBus()
+	bus.sessionErr = errors.New("no session")
+
+	watcher, err := newLogindWatcher(zap.NewNop().Sugar(), bus)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer watcher.Release()
+
+	if len(bus.matchRules) != 2 || strings.Contains(bus.matchRules[1], "path=") {
+		t.Fatalf("expected a match rule for any session, got %v", bus.matchRules)
+	}
+}
+
+func TestLogindWatcherEvents(t *testing.T) {
+	bus := newFakeLogindBus()
+
+	watcher, err := newLogindWatcher(zap.NewNop().Sugar(), bus)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := watcher.Events()
+
+	if held := bus.heldInhibitors(t); held != 1 {
+		t.Fatalf("expected a sleep inhibitor lock after starting, got %d", held)
+	}
+
+	bus.send(logindSessionInterface + ".Lock")
+	if event := receiveSystemEvent(t, events); event != SystemEventLock {
+		t.Fatalf("expected lock, got %v", event)
+	}
+
+	bus.send(logindSessionInterface + ".Unlock")
+	if event := receiveSystemEvent(t, events); event != SystemEventUnlock {
+		t.Fatalf("expected unlock, got %v", event)
+	}
+
+	// sleep is held off until the event is handled
+	bus.send(logindManagerInterface+".PrepareForSleep", true)
+	if event := receiveSystemEvent(t, events); event != SystemEventSleep {
+		t.Fatalf("expected sleep, got %v", event)
+	}
+
+	if held := bus.heldInhibitors(t); held != 1 {
+		t.Fatalf("expected the sleep inhibitor lock to be held while handling sleep, got %d", held)
+	}
+
+	watcher.EventHandled(SystemEventSleep)
+
+	if held := bus.heldInhibitors(t); held != 0 {
+		t.Fatalf("expected the sleep inhibitor lock to be released once sleep was handled, got %d", held)
+	}
+
+	// and taken again for the next sleep
+	bus.send(logindManagerInterface+".PrepareForSleep", false)
+	if event := receiveSystemEvent(t, events); event != SystemEventResume {
+		t.Fatalf("expected resume, got %v", event)
+	}
+
+	if held := bus.heldInhibitors(t); held != 1 {
+		t.Fatalf("expected a new sleep inhibitor lock after resuming, got %d", held)
+	}
+
+	// signals without a body or with unknown names are ignored
+	bus.send(logindManagerInterface + ".PrepareForSleep")
+	bus.send("org.example.Unrelated")
+
+	if err := watcher.Release(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := <-events; ok {
+		t.Fatal("expected the events channel to close with the bus")
+	}
+
+	if held := bus.heldInhibitors(t); held != 0 {
+		t.Fatalf("expected the sleep inhibitor lock to be released with the watcher, got %d", held)
+	}
+}
@@ -0,0 +1,24 @@
+package deej
+
+import (
+	"go.uber.org/zap"
+)
+
+// session lock and sleep events aren't watched on Windows yet, so this watcher never sends any
+type noopSystemEventWatcher struct {
+	events chan SystemEvent
+}
+
+func newSystemEventWatcher(logger *zap.SugaredLogger) (SystemEventWatcher, error) {
+	return &noopSystemEventWatcher{events: make(chan SystemEvent)}, nil
+}
+
+func (w *noopSystemEventWatcher) Events() <-chan SystemEvent {
+	return w.events
+}
+
+func (w *noopSystemEventWatcher) EventHandled(event SystemEvent) {}
+
+func (w *noopSystemEventWatcher) Release() error {
+	return nil
+}
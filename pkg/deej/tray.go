@@ -23,6 +23,9 @@ func (d *Deej) initializeTray(onDone func()) {
 		refreshSessions := systray.AddMenuItem("Re-scan audio sessions", "Manually refresh audio sessions if something's stuck")
 		refreshSessions.SetIcon(icon.RefreshSessions)
 
+		freezeSliders := systray.AddMenuItem("Freeze sliders", "Ignore slider input, e.g. while cleaning your faders")
+		freezeChanges := d.sessions.SubscribeToFreezeChanges()
+
 		if d.version != "" {
 			systray.AddSeparator()
 			versionInfo := systray.AddMenuItem(d.version, "")
@@ -63,6 +66,22 @@ func (d *Deej) initializeTray(onDone func()) {
 					// performance: the reason that forcing a refresh here is okay is that users can't spam the
 					// right-click -> select-this-option sequence at a rate that's meaningful to performance
 					d.sessions.refreshSessions(true)
+
+				// freeze or unfreeze sliders
+				case <-freezeSliders.ClickedCh:
+					logger.Info("Freeze sliders menu item clicked, toggling freeze")
+
+					go d.sessions.toggleFreeze()
+
+				// reflect the freeze state, regardless of where it was toggled from
+				case frozen := <-freezeChanges:
+					if frozen {
+						freezeSliders.Check()
+						systray.SetTooltip("deej (sliders frozen)")
+					} else {
+						freezeSliders.Uncheck()
+						systray.SetTooltip("deej")
+					}
 				}
 			}
 		}()
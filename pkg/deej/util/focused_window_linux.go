@@ -0,0 +1,282 @@
+package util
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// focusedWindowProvider finds the pid of the focused window's process in one particular desktop environment
+type focusedWindowProvider interface {
+	// focusedWindowPID returns 0 if no window is focused
+	focusedWindowPID() (int, error)
+}
+
+const (
+	getCurrentWindowInternalCooldown = time.Millisecond * 350
+
+	// a window manager (or X server) that's stuck shouldn't take deej's slider handling down with it
+	focusedWindowTimeout = time.Millisecond * 500
+
+	// i3's IPC protocol (which sway also speaks): a magic string, then the payload's length and the message type
+	i3IPCMagic       = "i3-ipc"
+	i3IPCTypeGetTree = 4
+
+	i3IPCNodeTypeWorkspace = "workspace"
+
+	hyprlandActiveWindowCommand = "j/activewindow"
+)
+
+var (
+	lastGetCurrentWindowResult []string
+	lastGetCurrentWindowCall   = time.Now()
+	getCurrentWindowLock       = &sync.Mutex{}
+
+	focusedWindowProviders     []focusedWindowProvider
+	focusedWindowProvidersOnce sync.Once
+)
+
+// focusedWindowPID asks each provider that fits the environment for the focused window, until one of them knows.
+// compositors go first, since an X11 display in a wayland session (xwayland) only knows about X11 windows
+func focusedWindowPID() (int, error) {
+	focusedWindowProvidersOnce.Do(func() {
+		if signature := os.Getenv("HYPRLAND_INSTANCE_SIGNATURE"); signature != "" {
+			focusedWindowProviders = append(focusedWindowProviders, &hyprlandWindowProvider{signature: signature})
+		}
+
+		for _, socketVariable := range []string{"SWAYSOCK", "I3SOCK"} {
+			if socketPath := os.Getenv(socketVariable); socketPath != "" {
+				focusedWindowProviders = append(focusedWindowProviders, &i3IPCWindowProvider{socketPath: socketPath})
+				break
+			}
+		}
+
+		if os.Getenv("DISPLAY") != "" {
+			focusedWindowProviders = append(focusedWindowProviders, &x11WindowProvider{})
+		}
+	})
+
+	if len(focusedWindowProviders) == 0 {
+		return 0, errors.New("no supported window manager or display server found")
+	}
+
+	var lastErr error
+
+	for _, provider := range focusedWindowProviders {
+		pid, err := provider.focusedWindowPID()
+		if err == nil {
+			return pid, nil
+		}
+
+		lastErr = err
+	}
+
+	return 0, lastErr
+}
+
+// x11WindowProvider reads _NET_ACTIVE_WINDOW off the root window, and _NET_WM_PID off that window.
+// the connection is kept open between calls, and re-opened if it breaks
+type x11WindowProvider struct {
+	conn *x11Connection
+}
+
+func (p *x11WindowProvider) focusedWindowPID() (int, error) {
+	if p.conn == nil {
+		conn, err := dialX11()
+		if err != nil {
+			return 0, fmt.Errorf("connect to X11: %w", err)
+		}
+
+		p.conn = conn
+	}
+
+	window, err := p.conn.cardinalProperty(p.conn.rootWindow, "_NET_ACTIVE_WINDOW")
+	if err != nil {
+
+		// the window manager may simply not support EWMH - that's not the connection's fault
+		p.closeIfBroken(err)
+
+		return 0, fmt.Errorf("get active X11 window: %w", err)
+	}
+
+	if window == 0 {
+		return 0, nil
+	}
+
+	pid, err := p.conn.cardinalProperty(window, "_NET_WM_PID")
+	if err != nil {
+
+		// plenty of windows don't say which process they belong to, which counts as nothing being focused
+		if errors.Is(err, errX11PropertyMissing) {
+			return 0, nil
+		}
+
+		// the window may have just closed, which makes the request fail without breaking the connection
+		p.closeIfBroken(err)
+
+		return 0, fmt.Errorf("get X11 window pid: %w", err)
+	}
+
+	return int(pid), nil
+}
+
+// closeIfBroken drops the connection after an error that leaves it in an unknown state (i.e. a timeout),
+// so that it's re-opened next time. errors the server replied with are fine, the connection's still in sync
+func (p *x11WindowProvider) closeIfBroken(err error) {
+	if errors.Is(err, errX11PropertyMissing) || errors.Is(err, errX11RequestFailed) {
+		return
+	}
+
+	p.conn.Close()
+	p.conn = nil
+}
+
+// i3IPCWindowProvider asks sway (or i3) for its window tree, and looks for the focused node in it.
+// i3 doesn't know window pids, so under i3 this gives way to the X11 provider
+type i3IPCWindowProvider struct {
+	socketPath string
+}
+
+type i3IPCNode struct {
+	Type          string      `json:"type"`
+	Focused       bool        `json:"focused"`
+	PID           int         `json:"pid"`
+	Nodes         []i3IPCNode `json:"nodes"`
+	FloatingNodes []i3IPCNode `json:"floating_nodes"`
+}
+
+func (p *i3IPCWindowProvider) focusedWindowPID() (int, error) {
+	conn, err := net.DialTimeout("unix", p.socketPath, focusedWindowTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("connect to window manager IPC socket: %w", err)
+	}
+
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(focusedWindowTimeout)); err != nil {
+		return 0, fmt.Errorf("set window manager IPC deadline: %w", err)
+	}
+
+	request := []byte(i3IPCMagic)
+	request = binary.NativeEndian.AppendUint32(request, 0)
+	request = binary.NativeEndian.AppendUint32(request, i3IPCTypeGetTree)
+
+	if _, err := conn.Write(request); err != nil {
+		return 0, fmt.Errorf("request window tree: %w", err)
+	}
+
+	header := make([]byte, len(i3IPCMagic)+8)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return 0, fmt.Errorf("read window tree: %w", err)
+	}
+
+	payload := make([]byte, binary.NativeEndian.Uint32(header[len(i3IPCMagic):]))
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return 0, fmt.Errorf("read window tree: %w", err)
+	}
+
+	var root i3IPCNode
+	if err := json.Unmarshal(payload, &root); err != nil {
+		return 0, fmt.Errorf("parse window tree: %w", err)
+	}
+
+	focused, ok := root.findFocused()
+	if !ok {
+		return 0, errors.New("no focused node in window tree")
+	}
+
+	// when an empty workspace has the focus, that's what's focused - and it has no pid
+	if focused.Type == i3IPCNodeTypeWorkspace {
+		return 0, nil
+	}
+
+	// i3 never reports pids, so let the X11 provider find this window's
+	if focused.PID <= 0 {
+		return 0, errors.New("focused window has no pid")
+	}
+
+	return focused.PID, nil
+}
+
+func (n *i3IPCNode) findFocused() (*i3IPCNode, bool) {
+	if n.Focused {
+		return n, true
+	}
+
+	for _, children := range [][]i3IPCNode{n.Nodes, n.FloatingNodes} {
+		for idx := range children {
+			if focused, ok := children[idx].findFocused(); ok {
+				return focused, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// hyprlandWindowProvider asks hyprland's request socket for the active window
+type hyprlandWindowProvider struct {
+	signature string
+}
+
+func (p *hyprlandWindowProvider) focusedWindowPID() (int, error) {
+
+	// newer versions keep their sockets in the runtime dir, older ones in /tmp
+	socketPaths := []string{filepath.Join("/tmp", "hypr", p.signature, ".socket.sock")}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		socketPaths = append([]string{filepath.Join(runtimeDir, "hypr", p.signature, ".socket.sock")}, socketPaths...)
+	}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	for _, socketPath := range socketPaths {
+		if conn, err = net.DialTimeout("unix", socketPath, focusedWindowTimeout); err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("connect to hyprland socket: %w", err)
+	}
+
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(focusedWindowTimeout)); err != nil {
+		return 0, fmt.Errorf("set hyprland socket deadline: %w", err)
+	}
+
+	if _, err := conn.Write([]byte(hyprlandActiveWindowCommand)); err != nil {
+		return 0, fmt.Errorf("request active window: %w", err)
+	}
+
+	// hyprland answers and closes the connection
+	response, err := io.ReadAll(conn)
+	if err != nil {
+		return 0, fmt.Errorf("read active window: %w", err)
+	}
+
+	var activeWindow struct {
+		PID int `json:"pid"`
+	}
+
+	if err := json.Unmarshal(response, &activeWindow); err != nil {
+		return 0, fmt.Errorf("parse active window: %w", err)
+	}
+
+	// no active window is reported as an empty object, or a pid of -1
+	if activeWindow.PID <= 0 {
+		return 0, nil
+	}
+
+	return activeWindow.PID, nil
+}
@@ -0,0 +1,127 @@
+package util
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+// serveI3IPC answers a single request on a fake sway socket with the given tree
+func serveI3IPC(t *testing.T, tree string) string {
+	t.Helper()
+
+	socketPath := filepath.Join(t.TempDir(), "sway-ipc.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		request := make([]byte, len(i3IPCMagic)+8)
+		if _, err := io.ReadFull(conn, request); err != nil {
+			return
+		}
+
+		reply := []byte(i3IPCMagic)
+		reply = binary.NativeEndian.AppendUint32(reply, uint32(len(tree)))
+		reply = binary.NativeEndian.AppendUint32(reply, i3IPCTypeGetTree)
+		reply = append(reply, tree...)
+
+		conn.Write(reply)
+	}()
+
+	return socketPath
+}
+
+func TestI3IPCFocusedWindowPID(t *testing.T) {
+	tests := []struct {
+		name    string
+		tree    string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "focused window",
+			tree: `{"focused": false, "nodes": [{"focused": false, "nodes": [{"focused": true, "pid": 4321}]}]}`,
+			want: 4321,
+		},
+		{
+			name: "focused floating window",
+			tree: `{"nodes": [{"nodes": [{"pid": 1111}], "floating_nodes": [{"focused": true, "pid": 2222}]}]}`,
+			want: 2222,
+		},
+		{
+			name: "focused empty workspace",
+			tree: `{"nodes": [{"nodes": [{"type": "workspace", "focused": true, "pid": 0, "nodes": []}]}]}`,
+			want: 0,
+		},
+		{
+			name:    "i3 focused window without a pid",
+			tree:    `{"nodes": [{"type": "workspace", "nodes": [{"type": "con", "focused": true, "window": 12582919}]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "nothing focused",
+			tree:    `{"nodes": [{"nodes": [{"pid": 1111}]}]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := &i3IPCWindowProvider{socketPath: serveI3IPC(t, test.tree)}
+
+			pid, err := provider.focusedWindowPID()
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error so the next provider is tried, got %d", pid)
+				}
+
+				return
+			}
+
+			if err != nil || pid != test.want {
+				t.Fatalf("got %d (%v), want %d", pid, err, test.want)
+			}
+		})
+	}
+}
+
+func TestI3IPCTimeout(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "sway-ipc.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer listener.Close()
+
+	// accept the connection, but never answer
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+		io.Copy(io.Discard, conn)
+	}()
+
+	provider := &i3IPCWindowProvider{socketPath: socketPath}
+	if _, err := provider.focusedWindowPID(); err == nil {
+		t.Fatal("expected a timeout")
+	}
+}
@@ -0,0 +1,162 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/go-ps"
+	"github.com/thoas/go-funk"
+)
+
+// ProcessStat holds the few fields of /proc/<pid>/stat that deej cares about
+type ProcessStat struct {
+	Comm         string
+	ParentPid    int
+	ProcessGroup int
+}
+
+const procDirectory = "/proc"
+
+// wine and proton start windows executables through one of these
+var wineLoaderNames = []string{"wine", "wine64", "wine-preloader", "wine64-preloader"}
+
+// ProcessNames returns the names a process is known by: the windows executable it runs (if it's a wine process),
+// followed by the name of its own executable. It returns nothing if the process can't be found
+func ProcessNames(pid int) []string {
+	names := []string{}
+
+	exeName := ""
+	if exePath, err := os.Readlink(procPath(pid, "exe")); err == nil {
+		exeName = filepath.Base(exePath)
+	} else if stat, ok := ReadProcessStat(pid); ok {
+
+		// other users' processes don't let us see their executable, but their command name is still there
+		exeName = stat.Comm
+	}
+
+	if funk.ContainsString(wineLoaderNames, exeName) {
+		if windowsName, ok := processWindowsExecutable(pid); ok {
+			names = append(names, windowsName)
+		}
+	}
+
+	if exeName != "" {
+		names = append(names, exeName)
+	}
+
+	return names
+}
+
+// ReadProcessStat reads a process' command name, parent and process group
+func ReadProcessStat(pid int) (ProcessStat, bool) {
+	contents, err := os.ReadFile(procPath(pid, "stat"))
+	if err != nil {
+		return ProcessStat{}, false
+	}
+
+	return parseProcessStat(string(contents))
+}
+
+// parseProcessStat reads the fields deej cares about from the contents of /proc/<pid>/stat
+func parseProcessStat(stat string) (ProcessStat, bool) {
+
+	// the command name is in parentheses and may contain anything (spaces and parentheses included),
+	// so the fields after it are found from the last closing parenthesis
+	commStart := strings.Index(stat, "(")
+	commEnd := strings.LastIndex(stat, ")")
+	if commStart < 0 || commEnd < commStart {
+		return ProcessStat{}, false
+	}
+
+	// state, ppid, pgrp
+	fields := strings.Fields(stat[commEnd+1:])
+	if len(fields) < 3 {
+		return ProcessStat{}, false
+	}
+
+	parentPid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return ProcessStat{}, false
+	}
+
+	processGroup, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return ProcessStat{}, false
+	}
+
+	return ProcessStat{
+		Comm:         stat[commStart+1 : commEnd],
+		ParentPid:    parentPid,
+		ProcessGroup: processGroup,
+	}, true
+}
+
+// ReadProcessFile returns the contents of one of a process' files under /proc (e.g. "cgroup")
+func ReadProcessFile(pid int, name string) ([]byte, error) {
+	contents, err := os.ReadFile(procPath(pid, name))
+	if err != nil {
+		return nil, fmt.Errorf("read %s of pid %d: %w", name, pid, err)
+	}
+
+	return contents, nil
+}
+
+// processWindowsExecutable finds the .exe a wine process is running from its command line.
+// its path can be a windows one ("C:\games\game.exe") or a unix one, depending on how it was started
+func processWindowsExecutable(pid int) (string, bool) {
+	cmdline, err := os.ReadFile(procPath(pid, "cmdline"))
+	if err != nil {
+		return "", false
+	}
+
+	return windowsExecutableFromCmdline(cmdline)
+}
+
+// windowsExecutableFromCmdline returns the name of the first .exe in a process' (nul-separated) command line
+func windowsExecutableFromCmdline(cmdline []byte) (string, bool) {
+	for _, arg := range strings.Split(string(cmdline), "\x00") {
+		if !strings.HasSuffix(strings.ToLower(arg), ".exe") {
+			continue
+		}
+
+		if separatorIdx := strings.LastIndexAny(arg, `\/`); separatorIdx >= 0 {
+			arg = arg[separatorIdx+1:]
+		}
+
+		return arg, true
+	}
+
+	return "", false
+}
+
+// processDescendants returns the pids of all of a process' children, their children and so on
+func processDescendants(pid int) ([]int, error) {
+	processes, err := ps.Processes()
+	if err != nil {
+		return nil, fmt.Errorf("list processes: %w", err)
+	}
+
+	children := make(map[int][]int)
+	for _, process := range processes {
+		children[process.PPid()] = append(children[process.PPid()], process.Pid())
+	}
+
+	result := []int{}
+	pending := children[pid]
+
+	for len(pending) > 0 {
+		child := pending[0]
+		pending = append(pending[1:], children[child]...)
+
+		result = append(result, child)
+	}
+
+	return result, nil
+}
+
+func procPath(pid int, name string) string {
+	return filepath.Join(procDirectory, fmt.Sprint(pid), name)
+}
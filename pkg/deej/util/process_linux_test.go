@@ -0,0 +1,113 @@
+package util
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseProcessStat(t *testing.T) {
+	tests := []struct {
+		name string
+		stat string
+		want ProcessStat
+		ok   bool
+	}{
+		{
+			name: "plain command name",
+			stat: "1234 (firefox) S 1000 1234 1234 0 -1 4194560 123 0 0 0",
+			want: ProcessStat{Comm: "firefox", ParentPid: 1000, ProcessGroup: 1234},
+			ok:   true,
+		},
+		{
+			name: "command name with spaces and parentheses",
+			stat: "42 (Web Content (x)) R 41 40 40 0 -1 0",
+			want: ProcessStat{Comm: "Web Content (x)", ParentPid: 41, ProcessGroup: 40},
+			ok:   true,
+		},
+		{
+			name: "truncated command name",
+			stat: "777 (wine64-preloade) S 1 777 777",
+			want: ProcessStat{Comm: "wine64-preloade", ParentPid: 1, ProcessGroup: 777},
+			ok:   true,
+		},
+		{
+			name: "missing fields",
+			stat: "1234 (firefox) S 1000",
+		},
+		{
+			name: "no command name",
+			stat: "1234 firefox S 1000 1234",
+		},
+		{
+			name: "invalid parent pid",
+			stat: "1234 (firefox) S abc 1234",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := parseProcessStat(test.stat)
+			if ok != test.ok || got != test.want {
+				t.Fatalf("got %+v (%v), want %+v (%v)", got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestReadProcessStatSelf(t *testing.T) {
+	stat, ok := ReadProcessStat(os.Getpid())
+	if !ok {
+		t.Fatal("expected to read our own stat")
+	}
+
+	if stat.ParentPid != os.Getppid() {
+		t.Fatalf("got parent pid %d, want %d", stat.ParentPid, os.Getppid())
+	}
+}
+
+func TestWindowsExecutableFromCmdline(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmdline string
+		want    string
+		ok      bool
+	}{
+		{
+			name:    "windows path",
+			cmdline: "C:\\Games\\Game\\Game.exe\x00-windowed\x00",
+			want:    "Game.exe",
+			ok:      true,
+		},
+		{
+			name:    "unix path after the loader",
+			cmdline: "/usr/bin/wine64-preloader\x00/home/user/games/setup.EXE\x00",
+			want:    "setup.EXE",
+			ok:      true,
+		},
+		{
+			name:    "bare name",
+			cmdline: "game.exe\x00",
+			want:    "game.exe",
+			ok:      true,
+		},
+		{
+			name:    "first of several executables",
+			cmdline: "launcher.exe\x00--run\x00Z:\\game.exe\x00",
+			want:    "launcher.exe",
+			ok:      true,
+		},
+		{
+			name:    "no executable",
+			cmdline: "/usr/bin/wine64-preloader\x00--version\x00",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := windowsExecutableFromCmdline([]byte(test.cmdline))
+			if ok != test.ok || got != test.want {
+				t.Fatalf("got %q (%v), want %q (%v)", got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
@@ -45,9 +45,21 @@ func SetupCloseHandler() chan os.Signal {
 	return c
 }
 
+// SetupFreezeToggleHandler creates a 'listener' that receives whenever the user asks deej to toggle
+// its freeze mode from outside (SIGUSR1 on Linux, i.e. "pkill -USR1 deej"). This never fires on Windows
+func SetupFreezeToggleHandler() chan os.Signal {
+	return setupFreezeToggleHandler()
+}
+
+// PauseMediaPlayers asks every media player that supports it to pause playback.
+// This is currently only implemented for Linux (using MPRIS)
+func PauseMediaPlayers() error {
+	return pauseMediaPlayers()
+}
+
 // GetCurrentWindowProcessNames returns the process names (including extension, if applicable)
 // of the current foreground window. This includes child processes belonging to the window.
-// This is currently only implemented for Windows
+// On Linux, this works with X11 (and XWayland windows), sway and Hyprland
 func GetCurrentWindowProcessNames() ([]string, error) {
 	return getCurrentWindowProcessNames()
 }
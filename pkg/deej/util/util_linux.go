@@ -1,9 +1,97 @@
 package util
 
 import (
-	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+const (
+	mprisNamePrefix = "org.mpris.MediaPlayer2."
+	mprisPath       = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	mprisPause      = "org.mpris.MediaPlayer2.Player.Pause"
 )
 
+func setupFreezeToggleHandler() chan os.Signal {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR1)
+
+	return c
+}
+
 func getCurrentWindowProcessNames() ([]string, error) {
-	return nil, errors.New("Not implemented")
+	getCurrentWindowLock.Lock()
+	defer getCurrentWindowLock.Unlock()
+
+	// same as on windows, don't go asking the display server or compositor on every single slider move
+	now := time.Now()
+	if lastGetCurrentWindowCall.Add(getCurrentWindowInternalCooldown).After(now) {
+		return lastGetCurrentWindowResult, nil
+	}
+
+	lastGetCurrentWindowCall = now
+
+	pid, err := focusedWindowPID()
+	if err != nil {
+		return nil, fmt.Errorf("get focused window pid: %w", err)
+	}
+
+	// nothing's focused (or it's something we can't tell the pid of)
+	if pid == 0 {
+		lastGetCurrentWindowResult = nil
+		return nil, nil
+	}
+
+	// much like windows' child windows, apps often play their audio from a child process (i.e. browsers),
+	// so all of the window's process tree is included
+	pids := []int{pid}
+	if descendants, err := processDescendants(pid); err == nil {
+		pids = append(pids, descendants...)
+	}
+
+	result := []string{}
+
+	for _, processPid := range pids {
+
+		// the first name is the one sessions are keyed by (i.e. a wine game's .exe rather than the wine loader)
+		if names := ProcessNames(processPid); len(names) > 0 {
+			result = append(result, names[0])
+		}
+	}
+
+	// cache & return whichever executable names we ended up with
+	lastGetCurrentWindowResult = result
+	return result, nil
+}
+
+func pauseMediaPlayers() error {
+	bus, err := dbus.SessionBus()
+	if err != nil {
+		return fmt.Errorf("connect to session bus: %w", err)
+	}
+
+	var names []string
+	if err := bus.BusObject().Call("org.freedesktop.DBus.ListNames", 0).Store(&names); err != nil {
+		return fmt.Errorf("list session bus names: %w", err)
+	}
+
+	// keep going if one player fails, but let the caller know about it
+	var lastErr error
+
+	for _, name := range names {
+		if !strings.HasPrefix(name, mprisNamePrefix) {
+			continue
+		}
+
+		if call := bus.Object(name, mprisPath).Call(mprisPause, 0); call.Err != nil {
+			lastErr = fmt.Errorf("pause %s: %w", name, call.Err)
+		}
+	}
+
+	return lastErr
 }
@@ -1,7 +1,9 @@
 package util
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"syscall"
 	"time"
 	"unsafe"
@@ -19,6 +21,11 @@ var (
 	lastGetCurrentWindowCall   = time.Now()
 )
 
+// there's no equivalent of SIGUSR1 on windows, so this channel is simply never written to
+func setupFreezeToggleHandler() chan os.Signal {
+	return make(chan os.Signal)
+}
+
 func getCurrentWindowProcessNames() ([]string, error) {
 
 	// apply an internal cooldown on this function to avoid calling windows API functions too frequently.
@@ -94,3 +101,7 @@ func getCurrentWindowProcessNames() ([]string, error) {
 	lastGetCurrentWindowResult = result
 	return result, nil
 }
+
+func pauseMediaPlayers() error {
+	return errors.New("Not implemented")
+}
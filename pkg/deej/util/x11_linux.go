@@ -0,0 +1,327 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// x11Connection speaks just enough of the X11 protocol to read window properties off the root window
+// and its children, which is all that's needed to find the focused window's pid.
+// it isn't safe for concurrent use
+type x11Connection struct {
+	conn       net.Conn
+	rootWindow uint32
+	atoms      map[string]uint32
+}
+
+const (
+	x11AuthName = "MIT-MAGIC-COOKIE-1"
+
+	// X authority file address families
+	x11FamilyLocal = 256
+	x11FamilyWild  = 65535
+
+	x11OpcodeInternAtom  = 16
+	x11OpcodeGetProperty = 20
+
+	x11ReplyError = 0
+	x11Reply      = 1
+
+	x11SetupFailed  = 0
+	x11SetupSuccess = 1
+)
+
+var (
+	errX11PropertyMissing = errors.New("property not set")
+
+	// the server answered a request with an error, which doesn't affect the connection itself
+	errX11RequestFailed = errors.New("request failed")
+)
+
+// dialX11 connects to the display in $DISPLAY, authenticating with a cookie from the X authority file if there's one
+func dialX11() (*x11Connection, error) {
+	display := os.Getenv("DISPLAY")
+	if display == "" {
+		return nil, errors.New("DISPLAY not set")
+	}
+
+	host, displayNumber, err := parseX11Display(display)
+	if err != nil {
+		return nil, fmt.Errorf("parse display %q: %w", display, err)
+	}
+
+	var conn net.Conn
+	if host == "" || host == "unix" {
+		conn, err = net.DialTimeout("unix", fmt.Sprintf("/tmp/.X11-unix/X%s", displayNumber), focusedWindowTimeout)
+	} else {
+		port, _ := strconv.Atoi(displayNumber)
+		conn, err = net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(6000+port)), focusedWindowTimeout)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("connect to display %q: %w", display, err)
+	}
+
+	x := &x11Connection{conn: conn, atoms: make(map[string]uint32)}
+
+	if err := x.setup(x11AuthCookie(host, displayNumber)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("set up display connection: %w", err)
+	}
+
+	return x, nil
+}
+
+// parseX11Display splits a display name like ":0", ":1.0" or "localhost:10.0" into its host and display number
+func parseX11Display(display string) (string, string, error) {
+	separatorIdx := strings.LastIndex(display, ":")
+	if separatorIdx < 0 {
+		return "", "", errors.New("missing display number")
+	}
+
+	host := display[:separatorIdx]
+	displayNumber := display[separatorIdx+1:]
+
+	if dotIdx := strings.Index(displayNumber, "."); dotIdx >= 0 {
+		displayNumber = displayNumber[:dotIdx]
+	}
+
+	if _, err := strconv.Atoi(displayNumber); err != nil {
+		return "", "", fmt.Errorf("invalid display number: %w", err)
+	}
+
+	return host, displayNumber, nil
+}
+
+// x11AuthCookie looks up the display's cookie in the X authority file.
+// no cookie means connecting without one, which works when the server allows local connections
+func x11AuthCookie(host string, displayNumber string) []byte {
+	authorityPath := os.Getenv("XAUTHORITY")
+	if authorityPath == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+
+		authorityPath = filepath.Join(homeDir, ".Xauthority")
+	}
+
+	contents, err := os.ReadFile(authorityPath)
+	if err != nil {
+		return nil
+	}
+
+	if host == "" || host == "unix" {
+		host, _ = os.Hostname()
+	}
+
+	reader := bytes.NewReader(contents)
+
+	// each entry is a family followed by four length-prefixed fields: address, display number, auth name and data
+	for {
+		var family uint16
+		if err := binary.Read(reader, binary.BigEndian, &family); err != nil {
+			return nil
+		}
+
+		fields := make([][]byte, 4)
+		for fieldIdx := range fields {
+			var length uint16
+			if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+				return nil
+			}
+
+			fields[fieldIdx] = make([]byte, length)
+			if _, err := io.ReadFull(reader, fields[fieldIdx]); err != nil {
+				return nil
+			}
+		}
+
+		address, number, name, data := string(fields[0]), string(fields[1]), string(fields[2]), fields[3]
+
+		if family != x11FamilyWild && !(family == x11FamilyLocal && address == host) {
+			continue
+		}
+
+		if (number == "" || number == displayNumber) && name == x11AuthName {
+			return data
+		}
+	}
+}
+
+func (x *x11Connection) setup(cookie []byte) error {
+	authName := []byte{}
+	if cookie != nil {
+		authName = []byte(x11AuthName)
+	}
+
+	// little-endian, protocol version 11.0
+	request := []byte{'l', 0}
+	request = binary.LittleEndian.AppendUint16(request, 11)
+	request = binary.LittleEndian.AppendUint16(request, 0)
+	request = binary.LittleEndian.AppendUint16(request, uint16(len(authName)))
+	request = binary.LittleEndian.AppendUint16(request, uint16(len(cookie)))
+	request = append(request, 0, 0)
+	request = append(request, x11Pad(authName)...)
+	request = append(request, x11Pad(cookie)...)
+
+	if err := x.conn.SetDeadline(time.Now().Add(focusedWindowTimeout)); err != nil {
+		return fmt.Errorf("set deadline: %w", err)
+	}
+
+	if _, err := x.conn.Write(request); err != nil {
+		return fmt.Errorf("write setup request: %w", err)
+	}
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(x.conn, header); err != nil {
+		return fmt.Errorf("read setup reply: %w", err)
+	}
+
+	additional := make([]byte, int(binary.LittleEndian.Uint16(header[6:8]))*4)
+	if _, err := io.ReadFull(x.conn, additional); err != nil {
+		return fmt.Errorf("read setup reply: %w", err)
+	}
+
+	switch header[0] {
+	case x11SetupSuccess:
+	case x11SetupFailed:
+		reasonLength := int(header[1])
+		if reasonLength > len(additional) {
+			reasonLength = len(additional)
+		}
+
+		return fmt.Errorf("server refused connection: %s", additional[:reasonLength])
+	default:
+		return errors.New("server requires further authentication")
+	}
+
+	// the root window is the first field of the first screen, which comes after the vendor string and pixmap formats
+	if len(additional) < 32 {
+		return errors.New("setup reply too short")
+	}
+
+	vendorLength := int(binary.LittleEndian.Uint16(additional[16:18]))
+	formatCount := int(additional[21])
+
+	screenOffset := 32 + x11PaddedLength(vendorLength) + formatCount*8
+	if len(additional) < screenOffset+4 {
+		return errors.New("setup reply too short")
+	}
+
+	x.rootWindow = binary.LittleEndian.Uint32(additional[screenOffset : screenOffset+4])
+
+	return nil
+}
+
+// atom returns the id of an existing atom, caching it for next time
+func (x *x11Connection) atom(name string) (uint32, error) {
+	if atom, ok := x.atoms[name]; ok {
+		return atom, nil
+	}
+
+	// only-if-exists is set, since a missing atom just means no window has that property
+	request := []byte{x11OpcodeInternAtom, 1}
+	request = binary.LittleEndian.AppendUint16(request, uint16(2+x11PaddedLength(len(name))/4))
+	request = binary.LittleEndian.AppendUint16(request, uint16(len(name)))
+	request = append(request, 0, 0)
+	request = append(request, x11Pad([]byte(name))...)
+
+	reply, err := x.roundTrip(request)
+	if err != nil {
+		return 0, fmt.Errorf("intern atom %s: %w", name, err)
+	}
+
+	atom := binary.LittleEndian.Uint32(reply[8:12])
+	if atom == 0 {
+		return 0, fmt.Errorf("atom %s: %w", name, errX11PropertyMissing)
+	}
+
+	x.atoms[name] = atom
+
+	return atom, nil
+}
+
+// cardinalProperty reads the first 32-bit value of a window property (e.g. a window id or a pid)
+func (x *x11Connection) cardinalProperty(window uint32, name string) (uint32, error) {
+	property, err := x.atom(name)
+	if err != nil {
+		return 0, err
+	}
+
+	// don't delete, any type, starting at offset 0, reading a single 32-bit value
+	request := []byte{x11OpcodeGetProperty, 0}
+	request = binary.LittleEndian.AppendUint16(request, 6)
+	request = binary.LittleEndian.AppendUint32(request, window)
+	request = binary.LittleEndian.AppendUint32(request, property)
+	request = binary.LittleEndian.AppendUint32(request, 0)
+	request = binary.LittleEndian.AppendUint32(request, 0)
+	request = binary.LittleEndian.AppendUint32(request, 1)
+
+	reply, err := x.roundTrip(request)
+	if err != nil {
+		return 0, fmt.Errorf("get property %s: %w", name, err)
+	}
+
+	format := reply[1]
+	valueLength := binary.LittleEndian.Uint32(reply[16:20])
+
+	if format != 32 || valueLength < 1 || len(reply) < 36 {
+		return 0, fmt.Errorf("property %s: %w", name, errX11PropertyMissing)
+	}
+
+	return binary.LittleEndian.Uint32(reply[32:36]), nil
+}
+
+// roundTrip sends a request and returns its entire reply
+func (x *x11Connection) roundTrip(request []byte) ([]byte, error) {
+	if err := x.conn.SetDeadline(time.Now().Add(focusedWindowTimeout)); err != nil {
+		return nil, fmt.Errorf("set deadline: %w", err)
+	}
+
+	if _, err := x.conn.Write(request); err != nil {
+		return nil, fmt.Errorf("write request: %w", err)
+	}
+
+	// no events were asked for, so the next thing to arrive is either the reply or an error
+	reply := make([]byte, 32)
+	if _, err := io.ReadFull(x.conn, reply); err != nil {
+		return nil, fmt.Errorf("read reply: %w", err)
+	}
+
+	if reply[0] == x11ReplyError {
+		return nil, fmt.Errorf("%w with error code %d", errX11RequestFailed, reply[1])
+	}
+
+	if reply[0] != x11Reply {
+		return nil, fmt.Errorf("unexpected message type %d", reply[0])
+	}
+
+	additional := make([]byte, int(binary.LittleEndian.Uint32(reply[4:8]))*4)
+	if _, err := io.ReadFull(x.conn, additional); err != nil {
+		return nil, fmt.Errorf("read reply: %w", err)
+	}
+
+	return append(reply, additional...), nil
+}
+
+func (x *x11Connection) Close() error {
+	return x.conn.Close()
+}
+
+func x11PaddedLength(length int) int {
+	return (length + 3) &^ 3
+}
+
+func x11Pad(data []byte) []byte {
+	return append(append([]byte{}, data...), make([]byte, x11PaddedLength(len(data))-len(data))...)
+}
@@ -0,0 +1,322 @@
+package util
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// x11Exchange is a single request the fake server expects (by its length), and the reply it sends back
+type x11Exchange struct {
+	requestLength int
+	reply         []byte
+}
+
+// serveX11 plays the server's side of a connection, reading each request before sending its reply
+func serveX11(t *testing.T, conn net.Conn, exchanges []x11Exchange) {
+	t.Helper()
+
+	go func() {
+		for _, exchange := range exchanges {
+			request := make([]byte, exchange.requestLength)
+			if _, err := io.ReadFull(conn, request); err != nil {
+				return
+			}
+
+			if exchange.reply == nil {
+				continue
+			}
+
+			if _, err := conn.Write(exchange.reply); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// these replies follow what Xorg sends, trimmed down to the parts that are read
+func x11SetupReply(rootWindow uint32) []byte {
+	vendor := []byte("The X.Org Foundation")
+
+	additional := make([]byte, 32)
+	binary.LittleEndian.PutUint32(additional[0:4], 12101004)    // release number
+	binary.LittleEndian.PutUint32(additional[4:8], 0x04200000)  // resource id base
+	binary.LittleEndian.PutUint32(additional[8:12], 0x001fffff) // resource id mask
+	binary.LittleEndian.PutUint16(additional[16:18], uint16(len(vendor)))
+	binary.LittleEndian.PutUint16(additional[18:20], 65535) // maximum request length
+	additional[20] = 1                                      // screens
+	additional[21] = 2                                      // pixmap formats
+
+	additional = append(additional, x11Pad(vendor)...)
+	additional = append(additional, []byte{1, 1, 32, 0, 0, 0, 0, 0}...)   // depth 1
+	additional = append(additional, []byte{24, 32, 32, 0, 0, 0, 0, 0}...) // depth 24
+
+	screen := make([]byte, 40)
+	binary.LittleEndian.PutUint32(screen[0:4], rootWindow)
+	additional = append(additional, screen...)
+
+	header := []byte{x11SetupSuccess, 0}
+	header = binary.LittleEndian.AppendUint16(header, 11)
+	header = binary.LittleEndian.AppendUint16(header, 0)
+	header = binary.LittleEndian.AppendUint16(header, uint16(len(additional)/4))
+
+	return append(header, additional...)
+}
+
+func x11SetupFailedReply(reason string) []byte {
+	additional := x11Pad([]byte(reason))
+
+	header := []byte{x11SetupFailed, byte(len(reason))}
+	header = binary.LittleEndian.AppendUint16(header, 11)
+	header = binary.LittleEndian.AppendUint16(header, 0)
+	header = binary.LittleEndian.AppendUint16(header, uint16(len(additional)/4))
+
+	return append(header, additional...)
+}
+
+func x11InternAtomReply(sequence uint16, atom uint32) []byte {
+	reply := make([]byte, 32)
+	reply[0] = x11Reply
+	binary.LittleEndian.PutUint16(reply[2:4], sequence)
+	binary.LittleEndian.PutUint32(reply[8:12], atom)
+
+	return reply
+}
+
+func x11GetPropertyReply(sequence uint16, format byte, values ...uint32) []byte {
+	reply := make([]byte, 32)
+	reply[0] = x11Reply
+	reply[1] = format
+	binary.LittleEndian.PutUint16(reply[2:4], sequence)
+	binary.LittleEndian.PutUint32(reply[4:8], uint32(len(values)))
+
+	if len(values) > 0 {
+		binary.LittleEndian.PutUint32(reply[8:12], 6) // CARDINAL (or 33 for WINDOW, it isn't checked)
+	}
+
+	binary.LittleEndian.PutUint32(reply[16:20], uint32(len(values)))
+
+	for _, value := range values {
+		reply = binary.LittleEndian.AppendUint32(reply, value)
+	}
+
+	return reply
+}
+
+func x11ErrorReply(sequence uint16, code byte) []byte {
+	reply := make([]byte, 32)
+	reply[0] = x11ReplyError
+	reply[1] = code
+	binary.LittleEndian.PutUint16(reply[2:4], sequence)
+
+	return reply
+}
+
+const (
+	x11SetupRequestLength       = 12
+	x11GetPropertyRequestLength = 24
+)
+
+func x11InternAtomRequestLength(name string) int {
+	return 8 + x11PaddedLength(len(name))
+}
+
+func newTestX11Connection(t *testing.T, exchanges []x11Exchange) (*x11Connection, error) {
+	client, server := net.Pipe()
+
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	serveX11(t, server, exchanges)
+
+	x := &x11Connection{conn: client, atoms: make(map[string]uint32)}
+
+	return x, x.setup(nil)
+}
+
+func TestX11Setup(t *testing.T) {
+	x, err := newTestX11Connection(t, []x11Exchange{
+		{requestLength: x11SetupRequestLength, reply: x11SetupReply(0x000003a0)},
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if x.rootWindow != 0x000003a0 {
+		t.Fatalf("got root window %#x, want %#x", x.rootWindow, 0x000003a0)
+	}
+}
+
+func TestX11SetupRefused(t *testing.T) {
+	_, err := newTestX11Connection(t, []x11Exchange{
+		{requestLength: x11SetupRequestLength, reply: x11SetupFailedReply("No protocol specified")},
+	})
+
+	if err == nil || err.Error() != "server refused connection: No protocol specified" {
+		t.Fatalf("expected the server's reason, got %v", err)
+	}
+}
+
+func TestX11CardinalProperty(t *testing.T) {
+	x, err := newTestX11Connection(t, []x11Exchange{
+		{requestLength: x11SetupRequestLength, reply: x11SetupReply(0x000003a0)},
+
+		// the active window, interning its atom first
+		{requestLength: x11InternAtomRequestLength("_NET_ACTIVE_WINDOW"), reply: x11InternAtomReply(1, 0x1d5)},
+		{requestLength: x11GetPropertyRequestLength, reply: x11GetPropertyReply(2, 32, 0x04400007)},
+
+		// its pid
+		{requestLength: x11InternAtomRequestLength("_NET_WM_PID"), reply: x11InternAtomReply(3, 0x1a8)},
+		{requestLength: x11GetPropertyRequestLength, reply: x11GetPropertyReply(4, 32, 4321)},
+
+		// the atom is cached, so asking again goes straight to the property - which this window doesn't have
+		{requestLength: x11GetPropertyRequestLength, reply: x11GetPropertyReply(5, 0)},
+
+		// and a window that closed in the meantime
+		{requestLength: x11GetPropertyRequestLength, reply: x11ErrorReply(6, 3)},
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	window, err := x.cardinalProperty(x.rootWindow, "_NET_ACTIVE_WINDOW")
+	if err != nil || window != 0x04400007 {
+		t.Fatalf("got active window %#x (%v), want %#x", window, err, 0x04400007)
+	}
+
+	pid, err := x.cardinalProperty(window, "_NET_WM_PID")
+	if err != nil || pid != 4321 {
+		t.Fatalf("got pid %d (%v), want 4321", pid, err)
+	}
+
+	if _, err := x.cardinalProperty(0x04400008, "_NET_WM_PID"); !errors.Is(err, errX11PropertyMissing) {
+		t.Fatalf("expected a missing property, got %v", err)
+	}
+
+	if _, err := x.cardinalProperty(0x04400009, "_NET_WM_PID"); !errors.Is(err, errX11RequestFailed) {
+		t.Fatalf("expected a failed request, got %v", err)
+	}
+}
+
+func TestX11MissingAtom(t *testing.T) {
+	x, err := newTestX11Connection(t, []x11Exchange{
+		{requestLength: x11SetupRequestLength, reply: x11SetupReply(0x000003a0)},
+		{requestLength: x11InternAtomRequestLength("_NET_ACTIVE_WINDOW"), reply: x11InternAtomReply(1, 0)},
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := x.cardinalProperty(x.rootWindow, "_NET_ACTIVE_WINDOW"); !errors.Is(err, errX11PropertyMissing) {
+		t.Fatalf("expected a missing property, got %v", err)
+	}
+}
+
+func TestX11Timeout(t *testing.T) {
+	x, err := newTestX11Connection(t, []x11Exchange{
+		{requestLength: x11SetupRequestLength, reply: x11SetupReply(0x000003a0)},
+
+		// the request is read, but never answered
+		{requestLength: x11InternAtomRequestLength("_NET_ACTIVE_WINDOW")},
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Now()
+
+	_, err = x.cardinalProperty(x.rootWindow, "_NET_ACTIVE_WINDOW")
+	if err == nil || errors.Is(err, errX11PropertyMissing) || errors.Is(err, errX11RequestFailed) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*focusedWindowTimeout {
+		t.Fatalf("took %v to time out", elapsed)
+	}
+}
+
+func TestParseX11Display(t *testing.T) {
+	tests := []struct {
+		display       string
+		host          string
+		displayNumber string
+		wantErr       bool
+	}{
+		{display: ":0", displayNumber: "0"},
+		{display: ":1.0", displayNumber: "1"},
+		{display: "localhost:10.0", host: "localhost", displayNumber: "10"},
+		{display: "unix:2", host: "unix", displayNumber: "2"},
+		{display: "0", wantErr: true},
+		{display: ":a", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.display, func(t *testing.T) {
+			host, displayNumber, err := parseX11Display(test.display)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %q %q", host, displayNumber)
+				}
+
+				return
+			}
+
+			if err != nil || host != test.host || displayNumber != test.displayNumber {
+				t.Fatalf("got %q %q (%v), want %q %q", host, displayNumber, err, test.host, test.displayNumber)
+			}
+		})
+	}
+}
+
+func TestX11AuthCookie(t *testing.T) {
+	entry := func(family uint16, fields ...string) []byte {
+		data := binary.BigEndian.AppendUint16(nil, family)
+		for _, field := range fields {
+			data = binary.BigEndian.AppendUint16(data, uint16(len(field)))
+			data = append(data, field...)
+		}
+
+		return data
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	authority := append(append(append(
+		entry(x11FamilyLocal, "otherhost", "0", x11AuthName, "other"),
+		entry(x11FamilyLocal, hostname, "1", x11AuthName, "display1")...),
+		entry(x11FamilyLocal, hostname, "0", "XDM-AUTHORIZATION-1", "xdm")...),
+		entry(x11FamilyLocal, hostname, "0", x11AuthName, "display0")...)
+
+	authorityPath := filepath.Join(t.TempDir(), "Xauthority")
+	if err := os.WriteFile(authorityPath, authority, 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Setenv("XAUTHORITY", authorityPath)
+
+	if cookie := x11AuthCookie("", "0"); string(cookie) != "display0" {
+		t.Fatalf("got cookie %q, want %q", cookie, "display0")
+	}
+
+	if cookie := x11AuthCookie("", "1"); string(cookie) != "display1" {
+		t.Fatalf("got cookie %q, want %q", cookie, "display1")
+	}
+
+	if cookie := x11AuthCookie("", "2"); cookie != nil {
+		t.Fatalf("expected no cookie, got %q", cookie)
+	}
+}
@@ -0,0 +1,110 @@
+package deej
+
+import (
+	"sync"
+	"time"
+
+	"github.com/thoas/go-funk"
+)
+
+// volumeLock remembers the volume deej last set for one of a locked slider's targets, so that it can be
+// re-applied whenever one of the target's apps decides to change its own volume.
+// locks are kept per target rather than per session, so that dynamic targets (like deej.current) are resolved
+// again each time they're enforced, instead of pinning whichever sessions they matched at the time
+type volumeLock struct {
+	sliderIdx int
+	target    string
+
+	// the target's volume, before any schedule caps (those are applied per session when enforcing)
+	volume float32
+
+	// when the target's volume was first seen drifting from the locked one (zero if it isn't)
+	driftedAt time.Time
+}
+
+type volumeLocks struct {
+	m    map[volumeLockKey]*volumeLock
+	lock sync.Locker
+}
+
+type volumeLockKey struct {
+	sliderIdx int
+	target    string
+}
+
+const (
+	// how long a session's volume can stay different from the locked one before it's re-applied.
+	// this gives apps a moment to settle (and users a moment to notice) instead of fighting every change
+	volumeLockGracePeriod = time.Second * 2
+
+	// session volumes don't always read back exactly as they were set
+	volumeLockTolerance = 0.01
+)
+
+func newVolumeLocks() *volumeLocks {
+	return &volumeLocks{
+		m:    make(map[volumeLockKey]*volumeLock),
+		lock: &sync.Mutex{},
+	}
+}
+
+func (l *volumeLocks) set(sliderIdx int, target string, volume float32) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	key := volumeLockKey{sliderIdx, target}
+
+	// keep the drift timer of an existing lock, it's only the volume that changed
+	if existing, ok := l.m[key]; ok {
+		existing.volume = volume
+		return
+	}
+
+	l.m[key] = &volumeLock{
+		sliderIdx: sliderIdx,
+		target:    target,
+		volume:    volume,
+	}
+}
+
+// retain drops the slider's locks for any targets other than the given ones,
+// i.e. when a fallback slider moves on to a different target
+func (l *volumeLocks) retain(sliderIdx int, targets []string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for key := range l.m {
+		if key.sliderIdx == sliderIdx && !funk.ContainsString(targets, key.target) {
+			delete(l.m, key)
+		}
+	}
+}
+
+func (l *volumeLocks) delete(lock *volumeLock) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.m, volumeLockKey{lock.sliderIdx, lock.target})
+}
+
+// iterate calls f with a snapshot of every lock's state, so f is free to call back into the other methods.
+// f's changes to the drift timer are written back, unless the lock was replaced or removed in the meantime
+func (l *volumeLocks) iterate(f func(*volumeLock)) {
+	l.lock.Lock()
+	locks := make([]volumeLock, 0, len(l.m))
+	for _, value := range l.m {
+		locks = append(locks, *value)
+	}
+	l.lock.Unlock()
+
+	for idx := range locks {
+		lock := &locks[idx]
+		f(lock)
+
+		l.lock.Lock()
+		if current, ok := l.m[volumeLockKey{lock.sliderIdx, lock.target}]; ok {
+			current.driftedAt = lock.driftedAt
+		}
+		l.lock.Unlock()
+	}
+}